@@ -0,0 +1,317 @@
+// Package iyhealth provides health and readiness checks for services.
+//
+// Components register named checks with a Registry, which runs them
+// concurrently, caching their results, and serves an aggregated JSON
+// report over HTTP, e.g.,
+//
+//	live, ready := iyhealth.New(), iyhealth.New()
+//	ready.Register("db", db.PingContext, iyhealth.WithTimeout(time.Second))
+//	ready.Register("cache", cache.Ping, iyhealth.WithCritical(false))
+//
+//	mux.Handle("/healthz", live)
+//	mux.Handle("/readyz", ready)
+//
+// The readiness of a server run using iyhttp.Serve can be included by
+// registering its Readiness's Check method:
+//
+//	ready.Register("server", readiness.Check)
+package iyhealth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/incisively/goiy/iytime"
+)
+
+// Defaults for checks and registries.
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultCacheTTL = time.Second
+)
+
+// Check reports the health of a component, returning an error if the
+// component is unhealthy. A Check should return promptly once ctx is
+// done.
+type Check func(ctx context.Context) error
+
+// Status is the status of a check, or of a Registry as a whole.
+type Status string
+
+// Supported statuses.
+const (
+	// StatusOK means all checks passed.
+	StatusOK Status = "ok"
+
+	// StatusDegraded means only non-critical checks failed.
+	StatusDegraded Status = "degraded"
+
+	// StatusFailing means a critical check failed.
+	StatusFailing Status = "failing"
+)
+
+// Result is the result of running a check.
+type Result struct {
+	Status    Status          `json:"status"`
+	Critical  bool            `json:"critical"`
+	Duration  iytime.Duration `json:"duration"`
+	Error     string          `json:"error,omitempty"`
+	CheckedAt time.Time       `json:"checked_at"`
+}
+
+// Report is the aggregated result of running a Registry's checks.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Healthy determines if no critical checks failed.
+func (r Report) Healthy() bool {
+	return r.Status != StatusFailing
+}
+
+// checker runs a check, caching its result.
+type checker struct {
+	name     string
+	check    Check
+	timeout  time.Duration
+	critical bool
+
+	mu      sync.Mutex
+	result  Result
+	expires time.Time
+	running chan struct{} // closed when the running check completes.
+}
+
+// CheckOption is a functional option for checks registered with
+// Register.
+type CheckOption func(*checker)
+
+// WithTimeout sets how long the check may run for before it's
+// considered to have failed. The default is DefaultTimeout.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *checker) {
+		c.timeout = d
+	}
+}
+
+// WithCritical sets whether the check is critical. A failing critical
+// check causes the Registry to report StatusFailing, while a failing
+// non-critical check only causes it to report StatusDegraded. Checks
+// are critical by default.
+func WithCritical(critical bool) CheckOption {
+	return func(c *checker) {
+		c.critical = critical
+	}
+}
+
+// Registry holds a set of named checks.
+//
+// Registry implements http.Handler, running its checks and responding
+// with the Report as JSON, with the status code 503 Service Unavailable
+// if any critical check failed, and 200 OK otherwise.
+//
+// A Registry can be used simultaneously from multiple goroutines.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]*checker
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// Option is a functional option for the Registry type.
+type Option func(*Registry)
+
+// WithCacheTTL sets how long the result of a check is cached for, so
+// that frequent requests don't overload the components being checked.
+// The default is DefaultCacheTTL.
+func WithCacheTTL(d time.Duration) Option {
+	return func(r *Registry) {
+		r.ttl = d
+	}
+}
+
+// New returns a new Registry.
+func New(options ...Option) *Registry {
+	r := &Registry{checks: map[string]*checker{}, ttl: DefaultCacheTTL, now: time.Now}
+
+	// Apply any options.
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Register registers check under name, replacing any check already
+// registered under name.
+func (r *Registry) Register(name string, check Check, options ...CheckOption) {
+	c := &checker{name: name, check: check, timeout: DefaultTimeout, critical: true}
+
+	// Apply any options.
+	for _, option := range options {
+		option(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = c
+}
+
+// Unregister removes the check registered under name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Names returns the names of the registered checks, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs the registered checks concurrently, and returns a Report of
+// their results. Cached results are used for checks which have run
+// within the cache TTL, and concurrent calls share the results of
+// checks which are already running.
+//
+// If ctx is done before a check completes, the check is reported as
+// failing, but continues to run so that its result can be cached.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]*checker, 0, len(r.checks))
+	for _, c := range r.checks {
+		checks = append(checks, c)
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *checker) {
+			defer wg.Done()
+			results[i] = c.run(ctx, r.ttl, r.now)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		res := results[i]
+		report.Checks[c.name] = res
+		if res.Status == StatusOK {
+			continue
+		}
+
+		if res.Critical {
+			report.Status = StatusFailing
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// ServeHTTP runs the checks and responds with the Report.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	report := r.Run(req.Context())
+
+	code := http.StatusOK
+	if !report.Healthy() {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
+
+// run returns the cached result of the check if it hasn't expired, and
+// otherwise the result of running the check, waiting for a check that's
+// already running if there is one.
+func (c *checker) run(ctx context.Context, ttl time.Duration, now func() time.Time) Result {
+	c.mu.Lock()
+	if !c.result.CheckedAt.IsZero() && now().Before(c.expires) {
+		res := c.result
+		c.mu.Unlock()
+		return res
+	}
+
+	if c.running == nil {
+		c.running = make(chan struct{})
+		go c.execute(ttl, now)
+	}
+	running := c.running
+	c.mu.Unlock()
+
+	select {
+	case <-running:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.result
+	case <-ctx.Done():
+		return Result{
+			Status:    StatusFailing,
+			Critical:  c.critical,
+			Error:     ctx.Err().Error(),
+			CheckedAt: now(),
+		}
+	}
+}
+
+// execute runs the check, caching its result. The check is run with its
+// own timeout, independent of the context of any caller, so that a
+// cancelled request doesn't cause a failing result to be cached.
+func (c *checker) execute(ttl time.Duration, now func() time.Time) {
+	start := now()
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				errc <- fmt.Errorf("panic: %v", v)
+			}
+		}()
+		errc <- c.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %v", c.timeout)
+	}
+
+	end := now()
+	res := Result{
+		Status:    StatusOK,
+		Critical:  c.critical,
+		Duration:  iytime.Duration(end.Sub(start)),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status, res.Error = StatusFailing, err.Error()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.result, c.expires = res, end.Add(ttl)
+	close(c.running)
+	c.running = nil
+}
@@ -0,0 +1,218 @@
+package iyhealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	pass = func(context.Context) error { return nil }
+	fail = func(context.Context) error { return errors.New("connection refused") }
+)
+
+func TestRegistry_Run(t *testing.T) {
+	examples := []struct {
+		critical    Check
+		nonCritical Check
+		status      Status
+	}{
+		{critical: pass, nonCritical: pass, status: StatusOK},
+		{critical: pass, nonCritical: fail, status: StatusDegraded},
+		{critical: fail, nonCritical: pass, status: StatusFailing},
+		{critical: fail, nonCritical: fail, status: StatusFailing},
+	}
+
+	for i, ex := range examples {
+		r := New()
+		r.Register("db", ex.critical)
+		r.Register("cache", ex.nonCritical, WithCritical(false))
+
+		report := r.Run(context.Background())
+		if report.Status != ex.status {
+			t.Errorf("[Example %d] got %v, expected %v", i+1, report.Status, ex.status)
+		}
+		if report.Healthy() != (ex.status != StatusFailing) {
+			t.Errorf("[Example %d] got %v, expected %v", i+1, report.Healthy(), ex.status != StatusFailing)
+		}
+		if c := report.Checks["cache"]; c.Critical {
+			t.Errorf("[Example %d] expected cache check not to be critical", i+1)
+		}
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := New()
+	r.Register("db", fail)
+	r.Register("queue", pass)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, expected %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("got %q, expected %q", ct, "application/json; charset=utf-8")
+	}
+
+	var body struct {
+		Status string `json:"status"`
+		Checks map[string]struct {
+			Status   string `json:"status"`
+			Critical bool   `json:"critical"`
+			Duration string `json:"duration"`
+			Error    string `json:"error"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Status != "failing" {
+		t.Errorf("got %q, expected %q", body.Status, "failing")
+	}
+	if db := body.Checks["db"]; db.Status != "failing" || db.Error != "connection refused" || !db.Critical {
+		t.Errorf("got %+v, expected a failing critical check", db)
+	}
+	if q := body.Checks["queue"]; q.Status != "ok" || q.Error != "" {
+		t.Errorf("got %+v, expected a passing check", q)
+	}
+	if _, err := time.ParseDuration(body.Checks["queue"].Duration); err != nil {
+		t.Error(err)
+	}
+
+	// A healthy registry responds with 200 OK.
+	r.Unregister("db")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("got %d, expected %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	var calls int32
+	now := time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	r := New(WithCacheTTL(time.Minute))
+	r.now = func() time.Time { return now }
+	r.Register("db", func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	r.Run(context.Background())
+	r.Run(context.Background())
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("got %d calls, expected %d", n, 1)
+	}
+
+	// The check is run again once the cached result expires.
+	now = now.Add(time.Minute)
+	r.Run(context.Background())
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("got %d calls, expected %d", n, 2)
+	}
+}
+
+func TestRegistry_Concurrent(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	r := New()
+	r.Register("slow", func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	})
+	r.Register("fast", pass)
+
+	// Concurrent runs share a running check.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if report := r.Run(context.Background()); report.Status != StatusOK {
+				t.Errorf("got %v, expected %v", report.Status, StatusOK)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("got %d calls, expected %d", n, 1)
+	}
+}
+
+func TestRegistry_Failures(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	r := New()
+	r.Register("timeout", func(ctx context.Context) error {
+		<-block
+		return nil
+	}, WithTimeout(10*time.Millisecond))
+	r.Register("panic", func(context.Context) error {
+		panic("boom")
+	})
+
+	report := r.Run(context.Background())
+	if err := report.Checks["timeout"].Error; err != "timed out after 10ms" {
+		t.Errorf("got %q, expected %q", err, "timed out after 10ms")
+	}
+	if err := report.Checks["panic"].Error; err != "panic: boom" {
+		t.Errorf("got %q, expected %q", err, "panic: boom")
+	}
+}
+
+func TestRegistry_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	r := New()
+	r.Register("slow", func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := r.Run(ctx)
+	if c := report.Checks["slow"]; c.Status != StatusFailing || !strings.Contains(c.Error, "canceled") {
+		t.Errorf("got %+v, expected a cancelled check", c)
+	}
+
+	// The check continues, and its result is cached.
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Run(ctx).Status != StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the cached result")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRegistry_Names(t *testing.T) {
+	r := New()
+	r.Register("queue", pass)
+	r.Register("db", pass)
+	r.Register("cache", pass)
+	r.Unregister("queue")
+
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"cache", "db"}) {
+		t.Errorf("got %v, expected %v", names, []string{"cache", "db"})
+	}
+}
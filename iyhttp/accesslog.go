@@ -0,0 +1,188 @@
+package iyhttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/incisively/goiy/iylog"
+)
+
+// AccessLogFormat is the format of the messages logged by AccessLog.
+type AccessLogFormat int
+
+// Supported access log formats.
+const (
+	// CombinedFormat logs each request as a message in the Apache
+	// combined log format, e.g.,
+	//
+	//	127.0.0.1 - frank [02/Jan/2006:15:04:05 -0700] "GET /a HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/5.0"
+	//
+	// The duration and request ID are attached as fields.
+	CombinedFormat AccessLogFormat = iota
+
+	// StructuredFormat logs each request as the message "request",
+	// with the details of the request attached as fields.
+	StructuredFormat
+)
+
+// accessLog is an http.Handler which logs each request handled by h.
+type accessLog struct {
+	h      http.Handler
+	logger *iylog.MultiLogger
+	format AccessLogFormat
+	skip   map[string]bool
+	now    func() time.Time
+}
+
+// AccessLogOption is a functional option for the AccessLog middleware.
+type AccessLogOption func(*accessLog)
+
+// WithAccessLogFormat sets the format requests are logged in. The
+// default is CombinedFormat.
+func WithAccessLogFormat(f AccessLogFormat) AccessLogOption {
+	return func(a *accessLog) {
+		a.format = f
+	}
+}
+
+// WithAccessLogger sets the MultiLogger requests are logged through. By
+// default requests are logged through the MultiLogger carried by the
+// request's context, as returned by iylog.FromContext.
+func WithAccessLogger(m *iylog.MultiLogger) AccessLogOption {
+	return func(a *accessLog) {
+		a.logger = m
+	}
+}
+
+// WithSkipPaths stops requests for any of paths, such as health
+// checks, from being logged.
+func WithSkipPaths(paths ...string) AccessLogOption {
+	return func(a *accessLog) {
+		for _, p := range paths {
+			a.skip[p] = true
+		}
+	}
+}
+
+// AccessLog returns an http.Handler which logs the method, path,
+// status, response size, duration, remote address, user agent and
+// request ID of each request handled by h, at INFO. Any error returned
+// by a HandlerFunc is attached as the "error" field.
+//
+// The request ID is taken from the request's context, as set by
+// RequestIDHandler, or otherwise the X-Request-ID header of the request
+// or response.
+//
+// The response is captured using a ResponseWriterShim, without
+// buffering the response body.
+func AccessLog(h http.Handler, options ...AccessLogOption) http.Handler {
+	a := &accessLog{h: h, skip: map[string]bool{}, now: time.Now}
+
+	// Apply any options.
+	for _, option := range options {
+		option(a)
+	}
+	return a
+}
+
+// ServeHTTP calls the wrapped handler and logs the request.
+func (a *accessLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if a.skip[r.URL.Path] {
+		a.h.ServeHTTP(w, r)
+		return
+	}
+
+	start := a.now()
+	shim := shimFor(w)
+	a.h.ServeHTTP(shim.ResponseWriter(), r)
+	d := a.now().Sub(start)
+
+	logger := a.logger
+	if logger == nil {
+		logger = iylog.FromContext(r.Context())
+	}
+
+	// The request ID is attached by InfoCtx if it's carried by the
+	// request's context.
+	var id string
+	if RequestID(r.Context()) == "" {
+		if id = r.Header.Get(RequestIDHeader); id == "" {
+			id = shim.Header().Get(RequestIDHeader)
+		}
+	}
+	if a.format == StructuredFormat {
+		logger = logger.With(
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", shim.Status(),
+			"size", shim.Size(),
+			"duration", d,
+			"remote_addr", r.RemoteAddr,
+			"user_agent", r.UserAgent(),
+		)
+		if id != "" {
+			logger = logger.With("request_id", id)
+		}
+		if err := shim.Err(); err != nil {
+			logger = logger.With("error", err)
+		}
+		logger.InfoCtx(r.Context(), "request")
+		return
+	}
+
+	logger = logger.With("duration", d)
+	if id != "" {
+		logger = logger.With("request_id", id)
+	}
+	if err := shim.Err(); err != nil {
+		logger = logger.With("error", err)
+	}
+	logger.InfoCtx(r.Context(), combined(r, shim, start))
+}
+
+// combined returns a line describing r and its response in the Apache
+// combined log format.
+func combined(r *http.Request, shim *ResponseWriterShim, t time.Time) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	} else if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	}
+
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+
+	size := "-"
+	if shim.Size() > 0 {
+		size = fmt.Sprint(shim.Size())
+	}
+
+	return fmt.Sprintf("%s - %s [%s] %q %d %s %q %q",
+		orDash(host),
+		user,
+		t.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method+" "+uri+" "+r.Proto,
+		shim.Status(),
+		size,
+		orDash(r.Referer()),
+		orDash(r.UserAgent()),
+	)
+}
+
+// orDash returns s, or "-" if s is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
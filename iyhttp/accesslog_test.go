@@ -0,0 +1,113 @@
+package iyhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/incisively/goiy/iylog"
+)
+
+// testClock returns a function returning start, and then start plus
+// d on each subsequent call.
+func testClock(start time.Time, d time.Duration) func() time.Time {
+	t := start.Add(-d)
+	return func() time.Time {
+		t = t.Add(d)
+		return t
+	}
+}
+
+func testAccessLog(format AccessLogFormat) (http.Handler, *iylog.MemLogger) {
+	mem := iylog.NewMemLogger()
+	h := AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		fmt.Fprint(w, "hello")
+	}), WithAccessLogFormat(format), WithAccessLogger(iylog.NewMultiLogger(mem)), WithSkipPaths("/healthz"))
+
+	h.(*accessLog).now = testClock(time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC), 250*time.Millisecond)
+	return h, mem
+}
+
+func testAccessRequest(path string) *http.Request {
+	r := httptest.NewRequest("GET", path, nil)
+	r.RemoteAddr = "10.0.0.1:5123"
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("User-Agent", "Mozilla/5.0")
+	r.Header.Set("Referer", "http://example.com/")
+	r.Header.Set("X-Request-ID", "a3f9")
+	return r
+}
+
+func TestAccessLog_Combined(t *testing.T) {
+	h, mem := testAccessLog(CombinedFormat)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, testAccessRequest("/a?b=c"))
+
+	if w.Code != http.StatusTeapot || w.Body.String() != "hello" {
+		t.Errorf("got %d %q, expected %d %q", w.Code, w.Body.String(), http.StatusTeapot, "hello")
+	}
+
+	entries := mem.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, expected 1", len(entries))
+	}
+
+	actual := entries[0].String()
+	expected := `[INFO] 10.0.0.1 - frank [02/Jan/2016:15:04:05 +0000] "GET /a?b=c HTTP/1.1" 418 5 "http://example.com/" "Mozilla/5.0" duration=250ms request_id=a3f9`
+	if actual != expected {
+		t.Errorf("got %s, expected %s", actual, expected)
+	}
+}
+
+func TestAccessLog_Structured(t *testing.T) {
+	h, mem := testAccessLog(StructuredFormat)
+	h.ServeHTTP(httptest.NewRecorder(), testAccessRequest("/a?b=c"))
+
+	entries := mem.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, expected 1", len(entries))
+	}
+
+	actual := entries[0].String()
+	expected := "[INFO] request method=GET path=/a status=418 size=5 duration=250ms remote_addr=10.0.0.1:5123 user_agent=Mozilla/5.0 request_id=a3f9"
+	if actual != expected {
+		t.Errorf("got %s, expected %s", actual, expected)
+	}
+}
+
+func TestAccessLog_SkipPaths(t *testing.T) {
+	h, mem := testAccessLog(CombinedFormat)
+	h.ServeHTTP(httptest.NewRecorder(), testAccessRequest("/healthz"))
+
+	if mem.Called() {
+		t.Errorf("expected no messages to be logged, got %s", mem.Dump())
+	}
+}
+
+func TestAccessLog_ContextLogger(t *testing.T) {
+	mem := iylog.NewMemLogger()
+	h := AccessLog(http.NotFoundHandler(), WithAccessLogFormat(StructuredFormat))
+
+	r := httptest.NewRequest("GET", "/missing", nil)
+	r = r.WithContext(iylog.NewContext(context.Background(), iylog.NewMultiLogger(mem).With("service", "api")))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	mem.AssertContains(t, "status=404")
+	mem.AssertContains(t, "service=api")
+}
+
+func TestAccessLog_HandlerError(t *testing.T) {
+	mem := iylog.NewMemLogger()
+	h := AccessLog(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return Error{Message: "not found", StatusCode: http.StatusNotFound}
+	}), WithAccessLogFormat(StructuredFormat), WithAccessLogger(iylog.NewMultiLogger(mem)))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/a", nil))
+
+	mem.AssertContains(t, "status=404")
+	mem.AssertContains(t, "error=not found")
+}
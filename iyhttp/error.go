@@ -1,7 +1,13 @@
 package iyhttp
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/incisively/goiy/iylog"
 )
 
 // Common standard errors.
@@ -36,6 +42,19 @@ func (e Error) Code() int {
 	return e.StatusCode
 }
 
+// LogFields returns the Error's status code, message and context, so
+// that they're attached as fields when the Error is logged using iylog.
+func (e Error) LogFields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"status_code": e.Code(),
+		"message":     e.Message,
+	}
+	if e.Context != "" {
+		fields["context"] = e.Context
+	}
+	return fields
+}
+
 // Error implements the error interface and returns the Error's Context
 // if it has one, or Message if it does not.
 func (e Error) Error() string {
@@ -44,3 +63,177 @@ func (e Error) Error() string {
 	}
 	return e.Message
 }
+
+// Problem is an RFC 7807 problem details object, describing an Error
+// for clients accepting application/problem+json.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// Problem returns the Error as a Problem, for the request r.
+func (e Error) Problem(r *http.Request) Problem {
+	p := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(e.Code()),
+		Status: e.Code(),
+		Detail: e.Message,
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+	return p
+}
+
+// AsError returns the Error in err's chain, found using errors.As. If
+// there is no Error in err's chain, ErrApplicationError is returned.
+//
+// ErrApplicationError is also returned for a *PanicError, even if the
+// value passed to panic was an Error, since a panic is never an
+// expected outcome of a request.
+func AsError(err error) Error {
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		return ErrApplicationError
+	}
+
+	var e Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var ep *Error
+	if errors.As(err, &ep) && ep != nil {
+		return *ep
+	}
+	return ErrApplicationError
+}
+
+// WriteError writes err to w as a JSON encoded Error, with the Error's
+// status code. If the request's Accept header prefers
+// application/problem+json to application/json, err is written as a
+// Problem instead.
+//
+// Errors which aren't an Error, and don't wrap one, are written as
+// ErrApplicationError, so that internal details aren't presented to
+// the client.
+//
+// err is logged through the MultiLogger carried by the request's
+// context, at ERROR for server errors and at INFO otherwise, with the
+// Error's Context attached. A *PanicError is logged with its stack
+// trace.
+//
+// If w is a ResponseWriterShim for a response which has already been
+// started, err is only logged, since the status code can no longer be
+// changed. If err is nil, WriteError does nothing.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	if acceptsProblem(r) {
+		WriteProblem(w, r, err)
+		return
+	}
+
+	e := AsError(err)
+	logError(r, err, e)
+	if started(w) {
+		return
+	}
+	e.StatusCode = e.Code()
+	writeJSON(w, "application/json", e.Code(), e)
+}
+
+// WriteProblem writes err to w as an RFC 7807 application/problem+json
+// response. err is mapped to an Error, and logged, as by WriteError.
+// If err is nil, WriteProblem does nothing.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	e := AsError(err)
+	logError(r, err, e)
+	if started(w) {
+		return
+	}
+	writeJSON(w, "application/problem+json", e.Code(), e.Problem(r))
+}
+
+// started determines if w writes through a ResponseWriterShim for a
+// response which has already been started.
+func started(w http.ResponseWriter) bool {
+	s, ok := w.(shimmed)
+	return ok && s.shim().Written()
+}
+
+// writeJSON writes v to w as JSON, with the given content type and
+// status code.
+func writeJSON(w http.ResponseWriter, contentType string, code int, v interface{}) {
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// logError logs err, which is being written to the client as e.
+func logError(r *http.Request, err error, e Error) {
+	ctx := r.Context()
+	logger := iylog.FromContext(ctx)
+	if e.Code() >= http.StatusInternalServerError {
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			logger = logger.With(iylog.StackKey, string(pe.Stack))
+		}
+		logger.ErrorCtx(ctx, err)
+		return
+	}
+
+	logger = logger.With("status_code", e.Code())
+	if e.Context != "" {
+		logger = logger.With("context", e.Context)
+	}
+	logger.InfoCtx(ctx, e.Message)
+}
+
+// jsonRanges are the media ranges matching application/json, by
+// specificity.
+var jsonRanges = map[string]int{"*/*": 0, "application/*": 1, "application/json": 2}
+
+// acceptsProblem determines if r prefers application/problem+json
+// responses to application/json ones. application/problem+json must be
+// named explicitly, and its q-value is compared to that of the most
+// specific media range matching application/json.
+func acceptsProblem(r *http.Request) bool {
+	var problem, plain float64
+	spec := -1
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mr := range strings.Split(accept, ",") {
+			params := strings.Split(mr, ";")
+			q := 1.0
+			for _, p := range params[1:] {
+				if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+					if q, ok = parseQ(v); !ok {
+						q = 0
+					}
+				}
+			}
+
+			t := strings.ToLower(strings.TrimSpace(params[0]))
+			if t == "application/problem+json" && q > problem {
+				problem = q
+			}
+			if s, ok := jsonRanges[t]; ok && s > spec {
+				spec, plain = s, q
+			}
+		}
+	}
+	return problem > 0 && problem >= plain
+}
+
+// parseQ parses the q-value v, which must be between 0 and 1.
+func parseQ(v string) (float64, bool) {
+	q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	return q, err == nil && q >= 0 && q <= 1
+}
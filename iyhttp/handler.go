@@ -0,0 +1,67 @@
+package iyhttp
+
+import (
+	"net/http"
+)
+
+// ErrorHandler handles an error returned by a HandlerFunc, typically
+// by writing it to the client.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// DefaultErrorHandler is the ErrorHandler used by HandlerFunc, unless
+// another is set with WithErrorHandler. It writes errors using
+// WriteError.
+var DefaultErrorHandler ErrorHandler = WriteError
+
+// HandlerFunc is an adapter allowing functions which return an error to
+// be used as HTTP handlers, e.g.,
+//
+//	http.Handle("/users/", iyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+//		u, err := findUser(r)
+//		if err != nil {
+//			return err
+//		}
+//		return json.NewEncoder(w).Encode(u)
+//	}))
+//
+// Returned errors are passed to DefaultErrorHandler. Panics are
+// recovered, and passed to the error handler as a *PanicError, which
+// is written as ErrApplicationError and logged with its stack trace.
+//
+// The response is written through a ResponseWriterShim, on which the
+// error is recorded, and which is reused if the handler is passed one,
+// e.g., by the AccessLog middleware.
+type HandlerFunc func(http.ResponseWriter, *http.Request) error
+
+// ServeHTTP calls f(w, r), handling any error using
+// DefaultErrorHandler.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serve(f, DefaultErrorHandler, w, r)
+}
+
+// WithErrorHandler returns an http.Handler which calls f, handling any
+// error using eh.
+func (f HandlerFunc) WithErrorHandler(eh ErrorHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serve(f, eh, w, r)
+	})
+}
+
+// serve calls f(w, r), recording any error or panic on the shim and
+// passing it to eh.
+func serve(f HandlerFunc, eh ErrorHandler, w http.ResponseWriter, r *http.Request) {
+	shim := shimFor(w)
+
+	defer func() {
+		if v := recover(); v != nil {
+			err := recovered(v)
+			shim.errRec = err
+			eh(shim.ResponseWriter(), r, err)
+		}
+	}()
+
+	if err := f(shim.ResponseWriter(), r); err != nil {
+		shim.errRec = err
+		eh(shim.ResponseWriter(), r, err)
+	}
+}
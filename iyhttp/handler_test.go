@@ -0,0 +1,134 @@
+package iyhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/incisively/goiy/iylog"
+)
+
+// testRequest returns a request carrying a MultiLogger which logs to
+// the returned MemLogger.
+func testRequest() (*http.Request, *iylog.MemLogger) {
+	mem := iylog.NewMemLogger()
+	r := httptest.NewRequest("GET", "/a", nil)
+	return r.WithContext(iylog.NewContext(context.Background(), iylog.NewMultiLogger(mem))), mem
+}
+
+func TestHandlerFunc(t *testing.T) {
+	notFound := Error{Message: "not found", StatusCode: http.StatusNotFound}
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return notFound
+	})
+
+	// The error is recorded on a shim the handler is passed.
+	r, _ := testRequest()
+	w := httptest.NewRecorder()
+	shim := NewResponseWriterShim(w)
+	h.ServeHTTP(shim, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got %d, expected %d", w.Code, http.StatusNotFound)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `{"message":"not found","status_code":404}` {
+		t.Errorf("got %s, expected %s", body, `{"message":"not found","status_code":404}`)
+	}
+	if shim.Err() != notFound {
+		t.Errorf("got %v, expected %v", shim.Err(), notFound)
+	}
+}
+
+func TestHandlerFunc_WithErrorHandler(t *testing.T) {
+	var handled error
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("oops")
+	}).WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	r, _ := testRequest()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("got %d, expected %d", w.Code, http.StatusTeapot)
+	}
+	if handled == nil || handled.Error() != "oops" {
+		t.Errorf("got %v, expected %v", handled, "oops")
+	}
+}
+
+func TestHandlerFunc_Panic(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	})
+
+	r, mem := testRequest()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got %d, expected %d", w.Code, http.StatusInternalServerError)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `{"message":"application error","status_code":500}` {
+		t.Errorf("got %s, expected %s", body, `{"message":"application error","status_code":500}`)
+	}
+
+	mem.AssertCountAt(t, iylog.ERROR, 1)
+	mem.AssertContains(t, "panic: boom")
+	mem.AssertContains(t, iylog.StackKey+"=goroutine")
+}
+
+func TestHandlerFunc_PanicWithError(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		panic(Error{Message: "not found", StatusCode: http.StatusNotFound})
+	})
+
+	r, mem := testRequest()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got %d, expected %d", w.Code, http.StatusInternalServerError)
+	}
+	mem.AssertCountAt(t, iylog.ERROR, 1)
+	mem.AssertContains(t, iylog.StackKey+"=goroutine")
+}
+
+func TestHandlerFunc_AbortHandler(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("got %v, expected %v", v, http.ErrAbortHandler)
+		}
+	}()
+	r, _ := testRequest()
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestHandlerFunc_Started(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		fmt.Fprint(w, "partial")
+		return errors.New("connection lost")
+	})
+
+	r, mem := testRequest()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	// The response can't be changed once started, so the error is only
+	// logged.
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("got %d %q, expected %d %q", w.Code, w.Body.String(), http.StatusOK, "partial")
+	}
+	mem.AssertContains(t, "connection lost")
+}
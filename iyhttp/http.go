@@ -1,10 +1,17 @@
 package iyhttp
 
 import (
+	"bufio"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 )
 
+// DefaultBodyLimit is the number of bytes of the response body a
+// ResponseWriterShim captures by default.
+const DefaultBodyLimit = 64 << 10
+
 // ResponseWriterShim shims an http.ResponseWriter, capturing all
 // modifications of headers, status code, or body.
 //
@@ -13,7 +20,23 @@ import (
 // to capture any modifications to the response for later logging or
 // processing.
 //
-// ResponseWriterShim implements the http.ResponseWriter interface.
+// The status code, headers and number of bytes written are always
+// captured, but only the first DefaultBodyLimit bytes of the body are
+// captured in Body, so that large or streamed responses aren't
+// buffered in memory. The limit can be changed with WithBodyLimit.
+//
+// ResponseWriterShim implements the http.ResponseWriter, http.Flusher,
+// http.Hijacker and io.ReaderFrom interfaces, passing calls on to the
+// wrapped http.ResponseWriter. Flush is a no-op, and Hijack returns
+// http.ErrNotSupported, if the wrapped http.ResponseWriter doesn't
+// support them. Unwrap returns the wrapped http.ResponseWriter, for use
+// by http.ResponseController.
+//
+// Since a handler may check for those interfaces to decide how to
+// respond, ResponseWriter returns an http.ResponseWriter which writes
+// through the shim, but only implements whichever of them the wrapped
+// http.ResponseWriter implements. Pass it on in preference to the shim
+// itself.
 //
 // Example:
 //
@@ -21,45 +44,305 @@ import (
 //		shim := iyhttp.NewResponseWriterShim(w)
 //
 //		// DoSomething expects an http.ResponseWriter.
-//		otherPkg.DoSomething(shim)
+//		otherPkg.DoSomething(shim.ResponseWriter())
 //
 //		// Now we have insight into what DoSomething did with the
 //		// http.ResponseWriter.
 //		log.Println(shim.Dump())
 //	}
 type ResponseWriterShim struct {
+	// ResponseRecorder holds the captured status code in Code, and the
+	// captured body in Body.
 	*httptest.ResponseRecorder
-	errRec error
-	w      http.ResponseWriter
+	errRec      error
+	w           http.ResponseWriter
+	rw          http.ResponseWriter // w's interface set, written through r.
+	limit       int64               // negative for no limit.
+	size        int64
+	wroteHeader bool
+	truncated   bool
+}
+
+// ShimOption is a functional option for the ResponseWriterShim type.
+type ShimOption func(*ResponseWriterShim)
+
+// WithBodyLimit sets the number of bytes of the response body the
+// ResponseWriterShim captures. A negative limit captures the whole
+// body.
+func WithBodyLimit(n int64) ShimOption {
+	return func(r *ResponseWriterShim) {
+		r.limit = n
+	}
+}
+
+// WithoutBody stops the ResponseWriterShim from capturing the response
+// body. It's equivalent to WithBodyLimit(0).
+func WithoutBody() ShimOption {
+	return WithBodyLimit(0)
 }
 
 // NewResponseWriterShim returns an initialialised shim.
-func NewResponseWriterShim(w http.ResponseWriter) *ResponseWriterShim {
+func NewResponseWriterShim(w http.ResponseWriter, options ...ShimOption) *ResponseWriterShim {
 	rec := httptest.NewRecorder()
 	rec.HeaderMap = w.Header()
-	return &ResponseWriterShim{ResponseRecorder: rec, w: w}
+	r := &ResponseWriterShim{ResponseRecorder: rec, w: w, limit: DefaultBodyLimit}
+	r.rw = r.wrap()
+
+	// Apply any options.
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// shimmed is implemented by a ResponseWriterShim, and by the
+// http.ResponseWriter returned by its ResponseWriter method.
+type shimmed interface {
+	shim() *ResponseWriterShim
+}
+
+func (r *ResponseWriterShim) shim() *ResponseWriterShim {
+	return r
+}
+
+// shimFor returns the ResponseWriterShim w writes through, if any, so
+// that middleware share a single shim, or otherwise a new shim wrapping
+// w which doesn't capture the body.
+func shimFor(w http.ResponseWriter) *ResponseWriterShim {
+	if s, ok := w.(shimmed); ok {
+		return s.shim()
+	}
+	return NewResponseWriterShim(w, WithoutBody())
+}
+
+// ResponseWriter returns an http.ResponseWriter which writes through the
+// shim, and which implements the same optional interfaces as the
+// wrapped http.ResponseWriter.
+func (r *ResponseWriterShim) ResponseWriter() http.ResponseWriter {
+	return r.rw
+}
+
+// shimWriter is the subset of a ResponseWriterShim's methods the
+// http.ResponseWriter returned by ResponseWriter always implements.
+type shimWriter interface {
+	http.ResponseWriter
+	io.StringWriter
+	Unwrap() http.ResponseWriter
+	shim() *ResponseWriterShim
+}
+
+// flusher, hijacker and readerFrom add the optional interfaces of the
+// wrapped http.ResponseWriter to the one returned by ResponseWriter.
+type flusher struct{ r *ResponseWriterShim }
+
+func (f flusher) Flush()            { f.r.Flush() }
+func (f flusher) FlushError() error { return f.r.FlushError() }
+
+type hijacker struct{ r *ResponseWriterShim }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) { return h.r.Hijack() }
+
+type readerFrom struct{ r *ResponseWriterShim }
+
+func (rf readerFrom) ReadFrom(src io.Reader) (int64, error) { return rf.r.ReadFrom(src) }
+
+// wrap returns an http.ResponseWriter writing through r which
+// implements each of http.Flusher, http.Hijacker and io.ReaderFrom only
+// if the wrapped http.ResponseWriter does.
+func (r *ResponseWriterShim) wrap() http.ResponseWriter {
+	_, f := r.w.(http.Flusher)
+	_, h := r.w.(http.Hijacker)
+	_, rf := r.w.(io.ReaderFrom)
+
+	switch {
+	case f && h && rf:
+		return struct {
+			shimWriter
+			flusher
+			hijacker
+			readerFrom
+		}{r, flusher{r}, hijacker{r}, readerFrom{r}}
+	case f && h:
+		return struct {
+			shimWriter
+			flusher
+			hijacker
+		}{r, flusher{r}, hijacker{r}}
+	case f && rf:
+		return struct {
+			shimWriter
+			flusher
+			readerFrom
+		}{r, flusher{r}, readerFrom{r}}
+	case h && rf:
+		return struct {
+			shimWriter
+			hijacker
+			readerFrom
+		}{r, hijacker{r}, readerFrom{r}}
+	case f:
+		return struct {
+			shimWriter
+			flusher
+		}{r, flusher{r}}
+	case h:
+		return struct {
+			shimWriter
+			hijacker
+		}{r, hijacker{r}}
+	case rf:
+		return struct {
+			shimWriter
+			readerFrom
+		}{r, readerFrom{r}}
+	}
+	return struct{ shimWriter }{r}
 }
 
 // Header returns the header map that will be sent by WriteHeader.
 // See net/http documentation for more information.
 func (r *ResponseWriterShim) Header() http.Header {
-	return r.ResponseRecorder.Header()
+	return r.w.Header()
 }
 
 // Write writes the data to the connection as part of an HTTP reply.
 // See net/http documentation for more information.
 func (r *ResponseWriterShim) Write(p []byte) (int, error) {
-	r.ResponseRecorder.Write(p)
-	return r.w.Write(p)
+	r.wroteHeader = true
+	r.capture(p)
+
+	n, err := r.w.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// WriteString writes s to the connection as part of an HTTP reply.
+func (r *ResponseWriterShim) WriteString(s string) (int, error) {
+	return r.Write([]byte(s))
+}
+
+// capture appends as much of p to Body as the limit allows.
+func (r *ResponseWriterShim) capture(p []byte) {
+	if r.limit >= 0 {
+		if room := r.limit - int64(r.Body.Len()); int64(len(p)) > room {
+			p, r.truncated = p[:room], true
+		}
+	}
+	r.Body.Write(p)
 }
 
 // WriteHeader sends an HTTP response header with status code.
 // See net/http documentation for more information.
+//
+// Informational (1xx) status codes other than 101 Switching Protocols
+// are passed on, but not captured.
 func (r *ResponseWriterShim) WriteHeader(i int) {
-	r.ResponseRecorder.WriteHeader(i)
+	informational := i >= 100 && i < 200 && i != http.StatusSwitchingProtocols
+	if !r.wroteHeader && !informational {
+		r.ResponseRecorder.Code, r.wroteHeader = i, true
+	}
 	r.w.WriteHeader(i)
 }
 
+// ReadFrom copies src to the connection as part of an HTTP reply,
+// allowing the wrapped http.ResponseWriter to use an optimised copy,
+// such as sendfile, once the body is no longer being captured.
+func (r *ResponseWriterShim) ReadFrom(src io.Reader) (int64, error) {
+	rf, ok := r.w.(io.ReaderFrom)
+	if !ok || r.limit < 0 {
+		return io.Copy(writerOnly{r}, src)
+	}
+
+	// Capture what's left of the limit before handing over.
+	var n int64
+	if room := r.limit - int64(r.Body.Len()); room > 0 {
+		var err error
+		if n, err = io.CopyN(writerOnly{r}, src, room); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return n, err
+		}
+	}
+
+	r.wroteHeader = true
+	m, err := rf.ReadFrom(src)
+	r.size += m
+	if m > 0 {
+		r.truncated = true
+	}
+	return n + m, err
+}
+
+// writerOnly hides any io.ReaderFrom implementation from io.Copy.
+type writerOnly struct {
+	io.Writer
+}
+
+// Flush sends any buffered data to the client, if the wrapped
+// http.ResponseWriter supports flushing.
+func (r *ResponseWriterShim) Flush() {
+	r.FlushError()
+}
+
+// FlushError sends any buffered data to the client, returning
+// http.ErrNotSupported if the wrapped http.ResponseWriter doesn't
+// support flushing.
+func (r *ResponseWriterShim) FlushError() error {
+	err := http.NewResponseController(r.w).Flush()
+	if err == nil {
+		r.Flushed, r.wroteHeader = true, true
+	}
+	return err
+}
+
+// Hijack lets the caller take over the connection, returning
+// http.ErrNotSupported if the wrapped http.ResponseWriter doesn't
+// support hijacking.
+func (r *ResponseWriterShim) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.w).Hijack()
+	if err == nil {
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (r *ResponseWriterShim) Unwrap() http.ResponseWriter {
+	return r.w
+}
+
+// Status returns the status code of the response. If no status code
+// has been written, http.StatusOK is returned.
+func (r *ResponseWriterShim) Status() int {
+	return r.ResponseRecorder.Code
+}
+
+// Size returns the number of bytes of the response body written to the
+// underlying http.ResponseWriter.
+func (r *ResponseWriterShim) Size() int64 {
+	return r.size
+}
+
+// Written determines if the response has been started, by writing a
+// status code or body, flushing, or hijacking the connection. Once the
+// response has been started its status code can no longer be changed.
+func (r *ResponseWriterShim) Written() bool {
+	return r.wroteHeader
+}
+
+// Err returns the error recorded by a HandlerFunc the shim was passed
+// to, if any.
+func (r *ResponseWriterShim) Err() error {
+	return r.errRec
+}
+
+// Truncated determines if more of the body was written than was
+// captured in Body.
+func (r *ResponseWriterShim) Truncated() bool {
+	return r.truncated
+}
+
 // Dump returns the captured response headers and body.
 func (r *ResponseWriterShim) Dump() string {
 	var data string
@@ -1,11 +1,19 @@
 package iyhttp
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 
 	"testing"
+
+	"github.com/incisively/goiy/iylog"
 )
 
 func TestNewResponseWriterShim(t *testing.T) {
@@ -82,3 +90,358 @@ func TestResponseWriterShim_Dump(t *testing.T) {
 		t.Errorf("got %s, expected %s", actual, expected)
 	}
 }
+
+func TestResponseWriterShim_BodyLimit(t *testing.T) {
+	in := httptest.NewRecorder()
+	shim := NewResponseWriterShim(in, WithBodyLimit(4))
+
+	fmt.Fprint(shim, "hel")
+	io.WriteString(shim, "lo world")
+
+	// It writes all the data to the underlying http.ResponseWriter.
+	if in.Body.String() != "hello world" {
+		t.Errorf("got %q, expected %q", in.Body.String(), "hello world")
+	}
+
+	// It only captures the data up to the limit.
+	if shim.Body.String() != "hell" {
+		t.Errorf("got %q, expected %q", shim.Body.String(), "hell")
+	}
+	if !shim.Truncated() {
+		t.Error("expected the captured body to be truncated")
+	}
+	if shim.Size() != 11 {
+		t.Errorf("got %d, expected %d", shim.Size(), 11)
+	}
+}
+
+func TestResponseWriterShim_WithoutBody(t *testing.T) {
+	in := httptest.NewRecorder()
+	shim := NewResponseWriterShim(in, WithoutBody())
+
+	fmt.Fprint(shim, "hello")
+
+	// It writes the data to the underlying http.ResponseWriter without
+	// capturing it.
+	if in.Body.String() != "hello" {
+		t.Errorf("got %q, expected %q", in.Body.String(), "hello")
+	}
+	if shim.Body.Len() != 0 {
+		t.Errorf("got %q, expected no captured body", shim.Body.String())
+	}
+
+	if shim.Status() != http.StatusOK {
+		t.Errorf("got %d, expected %d", shim.Status(), http.StatusOK)
+	}
+	if shim.Size() != 5 {
+		t.Errorf("got %d, expected %d", shim.Size(), 5)
+	}
+}
+
+func TestResponseWriterShim_Status(t *testing.T) {
+	in := httptest.NewRecorder()
+	shim := NewResponseWriterShim(in)
+
+	if shim.Written() {
+		t.Error("expected the response not to have been started")
+	}
+
+	// Informational responses aren't captured.
+	shim.WriteHeader(http.StatusEarlyHints)
+	shim.WriteHeader(http.StatusCreated)
+	shim.WriteHeader(http.StatusInternalServerError)
+
+	if shim.Status() != http.StatusCreated {
+		t.Errorf("got %d, expected %d", shim.Status(), http.StatusCreated)
+	}
+	if !shim.Written() {
+		t.Error("expected the response to have been started")
+	}
+}
+
+func TestResponseWriterShim_Flush(t *testing.T) {
+	in := httptest.NewRecorder()
+	shim := NewResponseWriterShim(in)
+
+	if err := http.NewResponseController(shim.ResponseWriter()).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !in.Flushed {
+		t.Error("expected the underlying http.ResponseWriter to be flushed")
+	}
+	if !shim.Written() {
+		t.Error("expected the response to be started")
+	}
+
+	// The shim itself also passes flushes on.
+	in = httptest.NewRecorder()
+	shim = NewResponseWriterShim(in)
+	shim.Flush()
+	if !in.Flushed || !shim.Flushed {
+		t.Errorf("got %v and %v, expected both to be flushed", in.Flushed, shim.Flushed)
+	}
+
+	// Flushing is not supported by every http.ResponseWriter.
+	shim = NewResponseWriterShim(struct{ http.ResponseWriter }{in})
+	if err := http.NewResponseController(shim.ResponseWriter()).Flush(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("got %v, expected %v", err, http.ErrNotSupported)
+	}
+}
+
+// hijackRecorder is an http.ResponseWriter which supports hijacking.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, nil, nil
+}
+
+func TestResponseWriterShim_Hijack(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	shim := NewResponseWriterShim(hijackRecorder{ResponseRecorder: httptest.NewRecorder(), conn: c1})
+	hj, ok := shim.ResponseWriter().(http.Hijacker)
+	if !ok {
+		t.Fatal("expected an http.Hijacker")
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn != c1 {
+		t.Errorf("got %v, expected %v", conn, c1)
+	}
+
+	// Hijacking is not supported by every http.ResponseWriter.
+	shim = NewResponseWriterShim(httptest.NewRecorder())
+	if _, _, err := http.NewResponseController(shim.ResponseWriter()).Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("got %v, expected %v", err, http.ErrNotSupported)
+	}
+}
+
+// readerFromRecorder is an http.ResponseWriter which implements
+// io.ReaderFrom, recording the data it's passed.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	read string
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	b, err := io.ReadAll(src)
+	r.read += string(b)
+	return int64(len(b)), err
+}
+
+func TestResponseWriterShim_ReadFrom(t *testing.T) {
+	in := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	shim := NewResponseWriterShim(in, WithBodyLimit(4))
+
+	rf, ok := shim.ResponseWriter().(io.ReaderFrom)
+	if !ok {
+		t.Fatal("expected an io.ReaderFrom")
+	}
+	n, err := rf.ReadFrom(strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 11 || shim.Size() != 11 {
+		t.Errorf("got %d and %d, expected %d", n, shim.Size(), 11)
+	}
+
+	// The captured data is written, and the rest is handed over to the
+	// underlying io.ReaderFrom.
+	if in.Body.String() != "hell" || in.read != "o world" {
+		t.Errorf("got %q and %q, expected %q and %q", in.Body.String(), in.read, "hell", "o world")
+	}
+	if shim.Body.String() != "hell" {
+		t.Errorf("got %q, expected %q", shim.Body.String(), "hell")
+	}
+}
+
+func TestResponseWriterShim_ResponseWriter(t *testing.T) {
+	in := struct{ http.ResponseWriter }{httptest.NewRecorder()}
+	shim := NewResponseWriterShim(in)
+	w := shim.ResponseWriter()
+
+	// Only the interfaces of the wrapped http.ResponseWriter are
+	// implemented.
+	if _, ok := w.(http.Flusher); ok {
+		t.Error("got an http.Flusher, expected none")
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		t.Error("got an http.Hijacker, expected none")
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		t.Error("got an io.ReaderFrom, expected none")
+	}
+
+	// Middleware share the shim the response is written through.
+	if got := shimFor(w); got != shim {
+		t.Errorf("got %p, expected %p", got, shim)
+	}
+}
+
+func TestResponseWriterShim_Unwrap(t *testing.T) {
+	in := httptest.NewRecorder()
+	if shim := NewResponseWriterShim(in); shim.Unwrap() != in {
+		t.Errorf("got %v, expected %v", shim.Unwrap(), in)
+	}
+}
+
+func TestError_LogFields(t *testing.T) {
+	e := Error{Context: "user 42 missing", Message: "not found", StatusCode: http.StatusNotFound}
+
+	actual := fmt.Sprint(e.LogFields())
+	expected := "map[context:user 42 missing message:not found status_code:404]"
+	if actual != expected {
+		t.Errorf("got %s, expected %s", actual, expected)
+	}
+
+	// Context is omitted when empty, and the status code defaults.
+	actual = fmt.Sprint(Error{Message: "oops"}.LogFields())
+	expected = "map[message:oops status_code:500]"
+	if actual != expected {
+		t.Errorf("got %s, expected %s", actual, expected)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	examples := []struct {
+		err         error
+		accept      string
+		code        int
+		contentType string
+		body        string
+		logged      string
+	}{
+		{
+			err:         Error{Context: "user 42 missing", Message: "not found", StatusCode: http.StatusNotFound},
+			code:        http.StatusNotFound,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"message":"not found","status_code":404}`,
+			logged:      "[INFO] not found status_code=404 context=user 42 missing",
+		},
+		{
+			err:         fmt.Errorf("loading: %w", Error{Message: "oops"}),
+			code:        http.StatusInternalServerError,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"message":"oops","status_code":500}`,
+			logged:      "[ERROR] loading: oops",
+		},
+		{
+			err:         errors.New("db down"),
+			code:        http.StatusInternalServerError,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"message":"application error","status_code":500}`,
+			logged:      "[ERROR] db down",
+		},
+		{
+			err:         &Error{Message: "bad input", StatusCode: http.StatusBadRequest},
+			accept:      "application/json;q=0.5, application/problem+json",
+			code:        http.StatusBadRequest,
+			contentType: "application/problem+json; charset=utf-8",
+			body:        `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad input","instance":"/things/1"}`,
+			logged:      "[INFO] bad input status_code=400",
+		},
+		{
+			err:         Error{Message: "bad input", StatusCode: http.StatusBadRequest},
+			accept:      "application/problem+json;Q=0.9, */*;q=0.8",
+			code:        http.StatusBadRequest,
+			contentType: "application/problem+json; charset=utf-8",
+			body:        `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad input","instance":"/things/1"}`,
+			logged:      "[INFO] bad input status_code=400",
+		},
+		{
+			err:         Error{Message: "bad input", StatusCode: http.StatusBadRequest},
+			accept:      "application/json, application/problem+json;q=0.1",
+			code:        http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"message":"bad input","status_code":400}`,
+			logged:      "[INFO] bad input status_code=400",
+		},
+		{
+			err:         Error{Message: "bad input", StatusCode: http.StatusBadRequest},
+			accept:      "application/problem+json;q=0.5, application/*;q=0.1, */*",
+			code:        http.StatusBadRequest,
+			contentType: "application/problem+json; charset=utf-8",
+			body:        `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad input","instance":"/things/1"}`,
+			logged:      "[INFO] bad input status_code=400",
+		},
+		{
+			err:         Error{Message: "bad input", StatusCode: http.StatusBadRequest},
+			accept:      "application/problem+json;q=0",
+			code:        http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"message":"bad input","status_code":400}`,
+			logged:      "[INFO] bad input status_code=400",
+		},
+	}
+
+	for i, ex := range examples {
+		mem := iylog.NewMemLogger()
+		r := httptest.NewRequest("GET", "/things/1", nil)
+		r = r.WithContext(iylog.NewContext(context.Background(), iylog.NewMultiLogger(mem)))
+		if ex.accept != "" {
+			r.Header.Set("Accept", ex.accept)
+		}
+
+		w := httptest.NewRecorder()
+		WriteError(w, r, ex.err)
+
+		if w.Code != ex.code {
+			t.Errorf("[Example %d] got %d, expected %d", i+1, w.Code, ex.code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != ex.contentType {
+			t.Errorf("[Example %d] got %q, expected %q", i+1, ct, ex.contentType)
+		}
+		if body := strings.TrimSpace(w.Body.String()); body != ex.body {
+			t.Errorf("[Example %d] got %s, expected %s", i+1, body, ex.body)
+		}
+
+		entries := mem.Entries()
+		if len(entries) != 1 {
+			t.Fatalf("[Example %d] got %d entries, expected 1", i+1, len(entries))
+		}
+		if !strings.HasPrefix(entries[0].String(), ex.logged) {
+			t.Errorf("[Example %d] got %s, expected %s", i+1, entries[0].String(), ex.logged)
+		}
+	}
+}
+
+func TestWriteError_Nil(t *testing.T) {
+	for i, write := range []func(http.ResponseWriter, *http.Request, error){WriteError, WriteProblem} {
+		mem := iylog.NewMemLogger()
+		r := httptest.NewRequest("GET", "/things/1", nil)
+		r = r.WithContext(iylog.NewContext(context.Background(), iylog.NewMultiLogger(mem)))
+
+		// Nothing is written or logged for a nil error.
+		w := NewResponseWriterShim(httptest.NewRecorder())
+		write(w, r, nil)
+
+		if w.Written() {
+			t.Errorf("[Example %d] got status %d, expected nothing written", i+1, w.Status())
+		}
+		if n := len(mem.Entries()); n != 0 {
+			t.Errorf("[Example %d] got %d entries, expected 0", i+1, n)
+		}
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteProblem(w, httptest.NewRequest("GET", "/a", nil), errors.New("db down"))
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json; charset=utf-8" {
+		t.Errorf("got %q, expected %q", ct, "application/problem+json; charset=utf-8")
+	}
+
+	actual := strings.TrimSpace(w.Body.String())
+	expected := `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"application error","instance":"/a"}`
+	if actual != expected {
+		t.Errorf("got %s, expected %s", actual, expected)
+	}
+}
@@ -0,0 +1,163 @@
+package iyhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/incisively/goiy/iymetrics"
+)
+
+// RouteNamer returns the name of the route a request is for, used in
+// the names of the metrics recorded by Metrics. Requests for the same
+// route with different parameters, such as "/users/123" and
+// "/users/456", should have the same name, such as "GET /users/:id".
+type RouteNamer func(r *http.Request) string
+
+// UnmatchedRoute is the name of the route Metrics records requests
+// under when they're answered with 404 Not Found or 405 Method Not
+// Allowed, unless a RouteNamer is set with WithRouteNamer.
+const UnmatchedRoute = "unmatched"
+
+// DefaultRouteNamer names routes by the request's method and path,
+// replacing segments of the path which look like IDs with ":id", e.g.,
+// "GET /users/:id/posts".
+//
+// Segments are considered to be IDs if they're entirely digits, are
+// UUIDs, or are hex strings of at least 16 characters.
+func DefaultRouteNamer(r *http.Request) string {
+	segments := strings.Split(r.URL.Path, "/")
+	for i, s := range segments {
+		if isID(s) {
+			segments[i] = ":id"
+		}
+	}
+	return r.Method + " " + strings.Join(segments, "/")
+}
+
+// isID determines if the path segment s looks like an ID.
+func isID(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	digits, hex := true, true
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			digits = false
+		case c == '-' && len(s) == 36:
+			digits = false
+		default:
+			return false
+		}
+	}
+	return digits || (hex && len(s) >= 16)
+}
+
+// metricsHandler is an http.Handler which records metrics for each
+// request handled by h.
+type metricsHandler struct {
+	h         http.Handler
+	metrics   iymetrics.MetricsI
+	prefix    string
+	namer     RouteNamer
+	precision time.Duration
+}
+
+// MetricsOption is a functional option for the Metrics middleware.
+type MetricsOption func(*metricsHandler)
+
+// WithRouteNamer sets the RouteNamer used to name routes. The default
+// is DefaultRouteNamer, with unmatched requests named UnmatchedRoute.
+//
+// The RouteNamer is used for every request, including those which
+// don't match a route, so it must only return a bounded set of names,
+// or else every path requested, such as by a vulnerability scanner,
+// will create new metrics.
+func WithRouteNamer(n RouteNamer) MetricsOption {
+	return func(h *metricsHandler) {
+		h.namer = n
+	}
+}
+
+// WithMetricsPrefix sets the prefix of the metric names. The default is
+// "http".
+func WithMetricsPrefix(prefix string) MetricsOption {
+	return func(h *metricsHandler) {
+		h.prefix = prefix
+	}
+}
+
+// WithLatencyPrecision sets the precision latencies are recorded at.
+// The default is time.Millisecond.
+func WithLatencyPrecision(d time.Duration) MetricsOption {
+	return func(h *metricsHandler) {
+		h.precision = d
+	}
+}
+
+// Metrics returns an http.Handler which records metrics for each
+// request handled by h to m. For a request for the route "GET /users/:id"
+// the following metrics are recorded, with the default prefix:
+//
+//	http.requests                        count of all requests
+//	http.status.2xx                      count of all requests by status class
+//	http.GET /users/:id.requests         count of requests for the route
+//	http.GET /users/:id.status.2xx       count of requests for the route by status class
+//	http.GET /users/:id.latency          time taken to handle the request
+//	http.GET /users/:id.size             size of the response body in bytes
+//
+// By default routes are named using DefaultRouteNamer, except that
+// requests answered with 404 Not Found or 405 Method Not Allowed are
+// recorded under UnmatchedRoute, so that requests for arbitrary paths
+// don't create unbounded numbers of metrics. This can be changed with
+// WithRouteNamer.
+//
+// The status code and size of the response are observed using a
+// ResponseWriterShim.
+func Metrics(h http.Handler, m iymetrics.MetricsI, options ...MetricsOption) http.Handler {
+	mh := &metricsHandler{
+		h:         h,
+		metrics:   m,
+		prefix:    "http",
+		precision: time.Millisecond,
+	}
+
+	// Apply any options.
+	for _, option := range options {
+		option(mh)
+	}
+	return mh
+}
+
+// ServeHTTP calls the wrapped handler and records its metrics.
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	shim := shimFor(w)
+	h.h.ServeHTTP(shim.ResponseWriter(), r)
+
+	route := h.prefix + "." + h.route(r, shim.Status())
+	class := fmt.Sprintf(".status.%dxx", shim.Status()/100)
+
+	h.metrics.Count(h.prefix+".requests", 1)
+	h.metrics.Count(h.prefix+class, 1)
+	h.metrics.Count(route+".requests", 1)
+	h.metrics.Count(route+class, 1)
+	h.metrics.Time(start, route+".latency", h.precision)
+	h.metrics.Measure(route+".size", float64(shim.Size()))
+}
+
+// route returns the name of the route r is for, given the status code
+// it was answered with.
+func (h *metricsHandler) route(r *http.Request, status int) string {
+	switch {
+	case h.namer != nil:
+		return h.namer(r)
+	case status == http.StatusNotFound, status == http.StatusMethodNotAllowed:
+		return UnmatchedRoute
+	}
+	return DefaultRouteNamer(r)
+}
@@ -0,0 +1,87 @@
+package iyhttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultRouteNamer(t *testing.T) {
+	examples := []struct {
+		method string
+		path   string
+		route  string
+	}{
+		{method: "GET", path: "/", route: "GET /"},
+		{method: "GET", path: "/users/123", route: "GET /users/:id"},
+		{method: "POST", path: "/users/123/posts/456", route: "POST /users/:id/posts/:id"},
+		{method: "GET", path: "/things/5f0c6a9e-3c1b-4d2a-9b7e-2f1d8c4a6b3e", route: "GET /things/:id"},
+		{method: "GET", path: "/blobs/0123456789abcdef", route: "GET /blobs/:id"},
+		{method: "GET", path: "/users/cafe", route: "GET /users/cafe"},
+		{method: "GET", path: "/v2/users", route: "GET /v2/users"},
+	}
+
+	for i, ex := range examples {
+		r := httptest.NewRequest(ex.method, ex.path, nil)
+		if route := DefaultRouteNamer(r); route != ex.route {
+			t.Errorf("[Example %d] got %q, expected %q", i+1, route, ex.route)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := newTestMetrics()
+	h := Metrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/2" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "hello")
+	}), metrics, WithLatencyPrecision(time.Nanosecond))
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// Requests which aren't found are recorded under a single route.
+	expected := map[string]int{
+		"http.requests":                  3,
+		"http.status.2xx":                2,
+		"http.status.4xx":                1,
+		"http.GET /users/:id.requests":   2,
+		"http.GET /users/:id.status.2xx": 2,
+		"http.unmatched.requests":        1,
+		"http.unmatched.status.4xx":      1,
+	}
+	if !reflect.DeepEqual(metrics.counts, expected) {
+		t.Errorf("got %v, expected %v", metrics.counts, expected)
+	}
+
+	sizes := metrics.measures["http.GET /users/:id.size"]
+	if exp := []float64{5, 5}; !reflect.DeepEqual(sizes, exp) {
+		t.Errorf("got %v, expected %v", sizes, exp)
+	}
+	if n := len(metrics.times["http.GET /users/:id.latency"]); n != 2 {
+		t.Errorf("got %d latencies, expected %d", n, 2)
+	}
+	if sizes := metrics.measures["http.unmatched.size"]; !reflect.DeepEqual(sizes, []float64{19}) {
+		t.Errorf("got %v, expected %v", sizes, []float64{19})
+	}
+}
+
+func TestMetrics_Options(t *testing.T) {
+	metrics := newTestMetrics()
+	h := Metrics(http.NotFoundHandler(), metrics,
+		WithMetricsPrefix("api"),
+		WithRouteNamer(func(r *http.Request) string { return "users" }),
+	)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	// The RouteNamer also names requests which aren't found.
+	if n := metrics.counts["api.users.status.4xx"]; n != 1 {
+		t.Errorf("got %d, expected %d", n, 1)
+	}
+}
@@ -0,0 +1,105 @@
+package iyhttp
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/incisively/goiy/iymetrics"
+)
+
+// PanicError is an error describing a panic recovered while handling a
+// request.
+type PanicError struct {
+	Value interface{} // the value passed to panic.
+	Stack []byte      // the stack trace of the panicking goroutine.
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic, if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recovered returns a PanicError for v, a value returned by recover,
+// capturing the current goroutine's stack trace. It must be called
+// from the deferred function that recovered v.
+//
+// If v is http.ErrAbortHandler, which is used to abort a response
+// silently, it is re-panicked.
+func recovered(v interface{}) *PanicError {
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+	return &PanicError{Value: v, Stack: debug.Stack()}
+}
+
+// DefaultPanicCounter is the name of the counter incremented by Recover
+// for each recovered panic.
+const DefaultPanicCounter = "http.panics"
+
+// recoverer is an http.Handler which recovers panics in h.
+type recoverer struct {
+	h       http.Handler
+	metrics iymetrics.MetricsI
+	counter string
+}
+
+// RecoverOption is a functional option for the Recover middleware.
+type RecoverOption func(*recoverer)
+
+// WithPanicMetrics sets the MetricsI used to count recovered panics,
+// under the counter name. If name is empty, DefaultPanicCounter is
+// used.
+func WithPanicMetrics(m iymetrics.MetricsI, name string) RecoverOption {
+	return func(rc *recoverer) {
+		if name == "" {
+			name = DefaultPanicCounter
+		}
+		rc.metrics, rc.counter = m, name
+	}
+}
+
+// Recover returns an http.Handler which recovers panics in h, so that
+// a panicking handler doesn't kill the connection without a response.
+//
+// A recovered panic is logged at ERROR with its stack trace, through
+// the MultiLogger carried by the request's context, and written to the
+// client as ErrApplicationError using WriteError. If h had already
+// started the response, nothing more is written. The *PanicError is
+// recorded on the ResponseWriterShim the response is written through,
+// which is reused if Recover is passed one.
+//
+// Panics with the value http.ErrAbortHandler, used to abort a response
+// silently, are not recovered.
+func Recover(h http.Handler, options ...RecoverOption) http.Handler {
+	rc := &recoverer{h: h}
+
+	// Apply any options.
+	for _, option := range options {
+		option(rc)
+	}
+	return rc
+}
+
+// ServeHTTP calls the wrapped handler, recovering any panic.
+func (rc *recoverer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	shim := shimFor(w)
+
+	defer func() {
+		if v := recover(); v != nil {
+			err := recovered(v)
+			shim.errRec = err
+			if rc.metrics != nil {
+				rc.metrics.Count(rc.counter, 1)
+			}
+			WriteError(shim.ResponseWriter(), r, err)
+		}
+	}()
+	rc.h.ServeHTTP(shim.ResponseWriter(), r)
+}
@@ -0,0 +1,145 @@
+package iyhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/incisively/goiy/iylog"
+)
+
+// testMetrics implements iymetrics.MetricsI, recording counts,
+// measures and times by name.
+type testMetrics struct {
+	mu       sync.Mutex
+	counts   map[string]int
+	measures map[string][]float64
+	times    map[string][]time.Duration
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{
+		counts:   map[string]int{},
+		measures: map[string][]float64{},
+		times:    map[string][]time.Duration{},
+	}
+}
+
+func (m *testMetrics) Count(name string, i int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[name] += i
+	return nil
+}
+
+func (m *testMetrics) Measure(name string, v float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.measures[name] = append(m.measures[name], v)
+	return nil
+}
+
+func (m *testMetrics) Time(start time.Time, name string, precision time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.times[name] = append(m.times[name], time.Since(start)/precision)
+}
+
+func TestRecover(t *testing.T) {
+	metrics := newTestMetrics()
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("boom"))
+	}), WithPanicMetrics(metrics, ""))
+
+	r, mem := testRequest()
+	w := httptest.NewRecorder()
+	shim := NewResponseWriterShim(w)
+	h.ServeHTTP(shim, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got %d, expected %d", w.Code, http.StatusInternalServerError)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `{"message":"application error","status_code":500}` {
+		t.Errorf("got %s, expected %s", body, `{"message":"application error","status_code":500}`)
+	}
+
+	mem.AssertCountAt(t, iylog.ERROR, 1)
+	mem.AssertContains(t, "panic: boom")
+	mem.AssertContains(t, iylog.StackKey+"=goroutine")
+
+	if n := metrics.counts[DefaultPanicCounter]; n != 1 {
+		t.Errorf("got %d, expected %d", n, 1)
+	}
+
+	var pe *PanicError
+	if !errors.As(shim.Err(), &pe) || pe.Unwrap().Error() != "boom" {
+		t.Errorf("got %v, expected a *PanicError wrapping %q", shim.Err(), "boom")
+	}
+}
+
+func TestRecover_PanicWithError(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(Error{Message: "not found", StatusCode: http.StatusNotFound})
+	}))
+
+	r, mem := testRequest()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	// A panic is always an application error, whatever its value.
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got %d, expected %d", w.Code, http.StatusInternalServerError)
+	}
+	mem.AssertCountAt(t, iylog.ERROR, 1)
+	mem.AssertContains(t, iylog.StackKey+"=goroutine")
+}
+
+func TestRecover_Started(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, "partial")
+		panic("boom")
+	}))
+
+	r, mem := testRequest()
+	w := &countingRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(w, r)
+
+	// No second header or body is written, but the panic is still
+	// logged.
+	if w.headers != 1 || w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Errorf("got %d headers, %d %q, expected 1 header, %d %q", w.headers, w.Code, w.Body.String(), http.StatusAccepted, "partial")
+	}
+	mem.AssertContains(t, "panic: boom")
+}
+
+// countingRecorder is an httptest.ResponseRecorder which counts calls
+// to WriteHeader.
+type countingRecorder struct {
+	*httptest.ResponseRecorder
+	headers int
+}
+
+func (c *countingRecorder) WriteHeader(code int) {
+	c.headers++
+	c.ResponseRecorder.WriteHeader(code)
+}
+
+func TestRecover_AbortHandler(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("got %v, expected %v", v, http.ErrAbortHandler)
+		}
+	}()
+	r, _ := testRequest()
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
@@ -0,0 +1,204 @@
+package iyhttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/incisively/goiy/iylog"
+)
+
+// RequestIDHeader is the default header request IDs are read from and
+// written to.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen limits the length of request IDs accepted from
+// clients.
+const maxRequestIDLen = 128
+
+// requestIDKey is the context key for request IDs.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID
+// id. The ID is also attached to messages logged with ctx through
+// iylog, as the "request_id" field.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return iylog.ContextWithFields(ctx, "request_id", id)
+}
+
+// RequestID returns the request ID carried by ctx, or an empty string
+// if it carries none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewUUID returns a random (version 4) UUID, e.g.,
+// "5f0c6a9e-3c1b-4d2a-9b7e-2f1d8c4a6b3e". It panics if the system's
+// secure random number generator fails.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4.
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant.
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// crockford is the Crockford base32 alphabet used to encode ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID, a lexicographically sortable identifier
+// made up of a millisecond timestamp and 80 random bits, e.g.,
+// "01ARZ3NDEKTSV4RRFFQ69G5FAV". It panics if the system's secure random
+// number generator fails.
+func NewULID() string {
+	return newULID(time.Now())
+}
+
+// newULID returns a ULID for the time t.
+func newULID(t time.Time) string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(t.UnixMilli())<<16)
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(err)
+	}
+
+	// The 128 bits are encoded as 26 characters of 5 bits, preceded by
+	// two zero bits.
+	var buf [26]byte
+	for i := range buf {
+		var v byte
+		for j := 0; j < 5; j++ {
+			v <<= 1
+			if bit := 5*i + j - 2; bit >= 0 {
+				v |= b[bit/8] >> (7 - uint(bit%8)) & 1
+			}
+		}
+		buf[i] = crockford[v]
+	}
+	return string(buf[:])
+}
+
+// requestIDHandler is an http.Handler which attaches a request ID to
+// each request handled by h.
+type requestIDHandler struct {
+	h        http.Handler
+	header   string
+	generate func() string
+}
+
+// RequestIDOption is a functional option for the RequestIDHandler
+// middleware.
+type RequestIDOption func(*requestIDHandler)
+
+// WithRequestIDHeader sets the header request IDs are read from and
+// written to. The default is RequestIDHeader.
+func WithRequestIDHeader(name string) RequestIDOption {
+	return func(h *requestIDHandler) {
+		h.header = name
+	}
+}
+
+// WithRequestIDGenerator sets the function used to generate request
+// IDs. The default is NewUUID.
+func WithRequestIDGenerator(generate func() string) RequestIDOption {
+	return func(h *requestIDHandler) {
+		h.generate = generate
+	}
+}
+
+// RequestIDHandler returns an http.Handler which attaches a request ID
+// to the context of each request handled by h, where it can be
+// retrieved using RequestID, and echoes it in the response's headers.
+//
+// The request ID is read from the request's X-Request-ID header, so
+// that IDs are propagated between services. If the header is missing,
+// or isn't a valid ID, a new ID is generated.
+func RequestIDHandler(h http.Handler, options ...RequestIDOption) http.Handler {
+	rh := &requestIDHandler{h: h, header: RequestIDHeader, generate: NewUUID}
+
+	// Apply any options.
+	for _, option := range options {
+		option(rh)
+	}
+	return rh
+}
+
+// ServeHTTP attaches the request ID and calls the wrapped handler.
+func (h *requestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(h.header)
+	if !validRequestID(id) {
+		id = h.generate()
+	}
+
+	w.Header().Set(h.header, id)
+	h.h.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+}
+
+// validRequestID determines if id is a reasonable length, and made up
+// of printable ASCII characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDTransport is an http.RoundTripper which propagates request
+// IDs to outbound requests, setting the request ID carried by each
+// request's context as a header, e.g.,
+//
+//	client := &http.Client{Transport: &iyhttp.RequestIDTransport{}}
+//	req, _ := http.NewRequestWithContext(r.Context(), "GET", url, nil)
+//	resp, err := client.Do(req)
+type RequestIDTransport struct {
+	// Base is the RoundTripper used to make requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Header is the header the request ID is set as. If empty,
+	// RequestIDHeader is used.
+	Header string
+}
+
+// RoundTrip implements the http.RoundTripper interface. Requests which
+// already have the header set are passed on unchanged.
+func (t *RequestIDTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := t.Header
+	if header == "" {
+		header = RequestIDHeader
+	}
+
+	if id := RequestID(r.Context()); id != "" && r.Header.Get(header) == "" {
+		// A RoundTripper must not modify the request it's given.
+		r = r.Clone(r.Context())
+		r.Header.Set(header, id)
+	}
+	return base.RoundTrip(r)
+}
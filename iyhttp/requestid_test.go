@@ -0,0 +1,145 @@
+package iyhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/incisively/goiy/iylog"
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	examples := []struct {
+		header string
+		id     string
+	}{
+		{header: "", id: "generated"},
+		{header: "a3f9", id: "a3f9"},
+		{header: "has space", id: "generated"},
+		{header: strings.Repeat("a", 129), id: "generated"},
+	}
+
+	for i, ex := range examples {
+		var id string
+		h := RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id = RequestID(r.Context())
+		}), WithRequestIDGenerator(func() string { return "generated" }))
+
+		r := httptest.NewRequest("GET", "/", nil)
+		if ex.header != "" {
+			r.Header.Set("X-Request-ID", ex.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if id != ex.id {
+			t.Errorf("[Example %d] got %q, expected %q", i+1, id, ex.id)
+		}
+		if echoed := w.Header().Get("X-Request-ID"); echoed != ex.id {
+			t.Errorf("[Example %d] got %q, expected %q", i+1, echoed, ex.id)
+		}
+	}
+}
+
+func TestRequestIDHandler_Logging(t *testing.T) {
+	mem := iylog.NewMemLogger()
+	h := RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		iylog.InfoCtx(r.Context(), "handled")
+	}), WithRequestIDHeader("X-Correlation-ID"))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Correlation-ID", "a3f9")
+	r = r.WithContext(iylog.NewContext(context.Background(), iylog.NewMultiLogger(mem)))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	mem.AssertContains(t, "handled request_id=a3f9")
+	if echoed := w.Header().Get("X-Correlation-ID"); echoed != "a3f9" {
+		t.Errorf("got %q, expected %q", echoed, "a3f9")
+	}
+}
+
+func TestRequestIDHandler_AccessLog(t *testing.T) {
+	// The request ID is logged once, whichever handler is outermost.
+	for i, wrap := range []func(http.Handler, *iylog.MemLogger) http.Handler{
+		func(h http.Handler, mem *iylog.MemLogger) http.Handler {
+			return RequestIDHandler(AccessLog(h, WithAccessLogger(iylog.NewMultiLogger(mem))))
+		},
+		func(h http.Handler, mem *iylog.MemLogger) http.Handler {
+			return AccessLog(RequestIDHandler(h), WithAccessLogger(iylog.NewMultiLogger(mem)))
+		},
+	} {
+		mem := iylog.NewMemLogger()
+		h := wrap(http.NotFoundHandler(), mem)
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Request-ID", "a3f9")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+
+		if n := strings.Count(mem.Dump(), "request_id=a3f9"); n != 1 {
+			t.Errorf("[Example %d] got %d request IDs, expected 1: %s", i+1, n, mem.Dump())
+		}
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := NewUUID(), NewUUID()
+	if !re.MatchString(a) {
+		t.Errorf("got %q, expected a version 4 UUID", a)
+	}
+	if a == b {
+		t.Errorf("expected unique UUIDs, got %q twice", a)
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	re := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	t1 := time.Date(2016, 7, 30, 23, 54, 10, 259e6, time.UTC)
+
+	a := newULID(t1)
+	if !re.MatchString(a) {
+		t.Errorf("got %q, expected a ULID", a)
+	}
+
+	// The timestamp is encoded in the first 10 characters.
+	if exp := "01ARZ3NDEK"; a[:10] != exp {
+		t.Errorf("got %q, expected %q", a[:10], exp)
+	}
+
+	// ULIDs sort by time.
+	if b := newULID(t1.Add(time.Millisecond)); b <= a {
+		t.Errorf("expected %q to sort after %q", b, a)
+	}
+}
+
+// roundTripFunc is an adapter allowing functions to be used as
+// http.RoundTrippers.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestRequestIDTransport(t *testing.T) {
+	var sent string
+	tr := &RequestIDTransport{Base: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r.Header.Get("X-Request-ID")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})}
+
+	ctx := ContextWithRequestID(context.Background(), "a3f9")
+	r, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/", nil)
+	if _, err := tr.RoundTrip(r); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != "a3f9" {
+		t.Errorf("got %q, expected %q", sent, "a3f9")
+	}
+	if r.Header.Get("X-Request-ID") != "" {
+		t.Error("expected the original request not to be modified")
+	}
+}
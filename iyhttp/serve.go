@@ -0,0 +1,291 @@
+package iyhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/incisively/goiy/iylog"
+)
+
+// DefaultDrainTimeout is how long Serve waits for active requests to
+// complete, and for shutdown hooks to run, by default.
+const DefaultDrainTimeout = 30 * time.Second
+
+// ErrNotReady is returned by Readiness.Check when not ready.
+var ErrNotReady = errors.New("not ready")
+
+// Readiness reports whether a server is ready to receive requests. The
+// zero value is not ready.
+//
+// Readiness implements http.Handler, responding with 200 OK when ready
+// and 503 Service Unavailable otherwise, so it can be used directly as
+// a readiness probe endpoint. Serve marks a Readiness passed using
+// WithReadiness as ready while serving, and not ready while shutting
+// down, so that load balancers stop routing requests to the server
+// before it stops accepting them.
+type Readiness struct {
+	ready int32 // accessed atomically.
+}
+
+// Ready determines if the server is ready.
+func (r *Readiness) Ready() bool { return atomic.LoadInt32(&r.ready) == 1 }
+
+// SetReady sets whether the server is ready.
+func (r *Readiness) SetReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&r.ready, v)
+}
+
+// Check returns ErrNotReady if the server isn't ready.
+func (r *Readiness) Check(ctx context.Context) error {
+	if !r.Ready() {
+		return ErrNotReady
+	}
+	return nil
+}
+
+// ServeHTTP responds with the server's readiness.
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	status, code := "ready", http.StatusOK
+	if !r.Ready() {
+		status, code = "not ready", http.StatusServiceUnavailable
+	}
+	writeJSON(w, "application/json", code, map[string]string{"status": status})
+}
+
+// ShutdownHook is called by Serve once the server has shut down, e.g.,
+// to flush logs or metrics. The context passed to a ShutdownHook is
+// cancelled once the drain timeout passes.
+type ShutdownHook func(ctx context.Context) error
+
+// FlushHook returns a ShutdownHook which calls flush, such as
+// iylog.Flush or sh.Flush.
+func FlushHook(flush func() error) ShutdownHook {
+	return func(context.Context) error {
+		return flush()
+	}
+}
+
+// server holds the configuration of a call to Serve.
+type server struct {
+	signals   []os.Signal
+	drain     time.Duration
+	delay     time.Duration
+	readiness *Readiness
+	hooks     []ShutdownHook
+	listener  net.Listener
+}
+
+// ServeOption is a functional option for Serve.
+type ServeOption func(*server)
+
+// WithSignals sets the signals which cause the server to shut down.
+// The default is SIGINT and SIGTERM. Calling WithSignals with no
+// signals disables signal handling.
+func WithSignals(signals ...os.Signal) ServeOption {
+	return func(s *server) {
+		s.signals = signals
+	}
+}
+
+// WithDrainTimeout sets how long to wait for active requests to
+// complete when shutting down, before closing their connections. The
+// same timeout applies to running the shutdown hooks. The default is
+// DefaultDrainTimeout.
+func WithDrainTimeout(d time.Duration) ServeOption {
+	return func(s *server) {
+		s.drain = d
+	}
+}
+
+// WithShutdownDelay sets how long to wait after marking the server as
+// not ready, before it stops accepting requests, giving load balancers
+// time to notice. The default is no delay.
+func WithShutdownDelay(d time.Duration) ServeOption {
+	return func(s *server) {
+		s.delay = d
+	}
+}
+
+// WithReadiness sets a Readiness which is marked as ready while the
+// server is serving, and not ready once it starts shutting down.
+func WithReadiness(r *Readiness) ServeOption {
+	return func(s *server) {
+		s.readiness = r
+	}
+}
+
+// WithShutdownHook adds hooks, which are called in the order they're
+// added once the server has shut down.
+func WithShutdownHook(hooks ...ShutdownHook) ServeOption {
+	return func(s *server) {
+		s.hooks = append(s.hooks, hooks...)
+	}
+}
+
+// WithListener sets the listener the server accepts connections on. By
+// default the server listens on its Addr.
+func WithListener(l net.Listener) ServeOption {
+	return func(s *server) {
+		s.listener = l
+	}
+}
+
+// Serve runs srv until ctx is done or one of the shutdown signals is
+// received, then shuts it down gracefully, e.g.,
+//
+//	ready := &iyhttp.Readiness{}
+//	mux.Handle("/readyz", ready)
+//
+//	err := iyhttp.Serve(context.Background(), srv,
+//		iyhttp.WithReadiness(ready),
+//		iyhttp.WithShutdownDelay(5*time.Second),
+//		iyhttp.WithShutdownHook(iyhttp.FlushHook(sh.Flush), iyhttp.FlushHook(iylog.Flush)),
+//	)
+//	if err != nil {
+//		iylog.Fatal(err)
+//	}
+//
+// When shutting down, the server is marked as not ready, and after the
+// shutdown delay stops accepting connections. Active requests are
+// given until the drain timeout to complete, after which their
+// connections are closed. Finally the shutdown hooks are run.
+//
+// If srv has a TLSConfig, the server is served over TLS using its
+// certificates, and Serve returns an error if it has none, rather than
+// serving plaintext.
+//
+// The server is only marked as ready once it's listening. If it can't
+// listen on its Addr, Serve returns the error immediately, without
+// running the shutdown hooks. Once the server has started shutting
+// down, the shutdown signals are no longer handled, so sending one a
+// second time kills the process as usual.
+//
+// Serve returns nil after a graceful shutdown. Otherwise it returns
+// the errors from serving, draining connections and running the
+// shutdown hooks, joined using errors.Join. Shutdown hooks are run
+// even if the server fails while serving.
+func Serve(ctx context.Context, srv *http.Server, options ...ServeOption) error {
+	s := &server{
+		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+		drain:   DefaultDrainTimeout,
+	}
+
+	// Apply any options.
+	for _, option := range options {
+		option(s)
+	}
+
+	stop := func() {}
+	if len(s.signals) > 0 {
+		ctx, stop = signal.NotifyContext(ctx, s.signals...)
+	}
+	defer stop()
+
+	useTLS := srv.TLSConfig != nil
+	if useTLS && !hasCertificates(srv.TLSConfig) {
+		return errors.New("TLSConfig has no certificates")
+	}
+
+	ln := s.listener
+	if ln == nil {
+		addr := srv.Addr
+		if addr == "" {
+			addr = ":http"
+			if useTLS {
+				addr = ":https"
+			}
+		}
+
+		var err error
+		if ln, err = net.Listen("tcp", addr); err != nil {
+			return err
+		}
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		if useTLS {
+			errc <- srv.ServeTLS(ln, "", "")
+			return
+		}
+		errc <- srv.Serve(ln)
+	}()
+	s.setReady(true)
+
+	var errs []error
+	select {
+	case err := <-errc:
+		s.setReady(false)
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("serving: %w", err))
+		}
+	case <-ctx.Done():
+		stop()
+		iylog.FromContext(ctx).Info("shutting down")
+		s.setReady(false)
+		time.Sleep(s.delay)
+
+		sctx, cancel := context.WithTimeout(context.Background(), s.drain)
+		if err := srv.Shutdown(sctx); err != nil {
+			srv.Close()
+			errs = append(errs, fmt.Errorf("draining connections: %w", err))
+		}
+		cancel()
+
+		if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("serving: %w", err))
+		}
+	}
+
+	hctx, cancel := context.WithTimeout(context.Background(), s.drain)
+	defer cancel()
+	for _, hook := range s.hooks {
+		if err := runHook(hctx, hook); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasCertificates determines if c provides certificates, so that a
+// server can be served with it using ServeTLS without certificate
+// files.
+func hasCertificates(c *tls.Config) bool {
+	return len(c.Certificates) > 0 || c.GetCertificate != nil || c.GetConfigForClient != nil
+}
+
+// setReady sets the readiness of the server, if it has a Readiness.
+func (s *server) setReady(ready bool) {
+	if s.readiness != nil {
+		s.readiness.SetReady(ready)
+	}
+}
+
+// runHook calls hook, returning ctx's error if ctx is done before hook
+// returns.
+func runHook(ctx context.Context, hook ShutdownHook) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- hook(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
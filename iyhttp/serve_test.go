@@ -0,0 +1,230 @@
+package iyhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testListener returns a listener on a random local port.
+func testListener(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// waitReady waits until r is ready, or fails the test.
+func waitReady(t *testing.T, r *Readiness) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !r.Ready() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for readiness")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServe(t *testing.T) {
+	l := testListener(t)
+	ready := &Readiness{}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	})}
+
+	var hooks []string
+	hook := func(name string) ShutdownHook {
+		return func(context.Context) error {
+			hooks = append(hooks, name)
+			if !ready.Ready() {
+				return nil
+			}
+			return errors.New("expected not to be ready")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(ctx, srv,
+			WithListener(l),
+			WithReadiness(ready),
+			WithShutdownHook(hook("metrics"), hook("logs")),
+		)
+	}()
+	waitReady(t, ready)
+
+	resp, err := http.Get("http://" + l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hello" {
+		t.Errorf("got %q, expected %q", body, "hello")
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(hooks) != "[metrics logs]" {
+		t.Errorf("got %v, expected %v", hooks, "[metrics logs]")
+	}
+}
+
+func TestServe_DrainTimeout(t *testing.T) {
+	l := testListener(t)
+	ready := &Readiness{}
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	hookErr := errors.New("flush failed")
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(ctx, srv,
+			WithListener(l),
+			WithReadiness(ready),
+			WithDrainTimeout(50*time.Millisecond),
+			WithShutdownHook(func(context.Context) error { return hookErr }),
+		)
+	}()
+	waitReady(t, ready)
+
+	go http.Get("http://" + l.Addr().String())
+	<-started
+	cancel()
+
+	err := <-errc
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, expected %v", err, context.DeadlineExceeded)
+	}
+	if !errors.Is(err, hookErr) {
+		t.Errorf("got %v, expected %v", err, hookErr)
+	}
+}
+
+func TestServe_TLS(t *testing.T) {
+	// Borrow the test server's certificate, and a client trusting it.
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	config, client := ts.TLS.Clone(), ts.Client()
+	ts.Close()
+
+	l := testListener(t)
+	ready := &Readiness{}
+	srv := &http.Server{
+		TLSConfig: config,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, r.TLS != nil)
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Serve(ctx, srv, WithListener(l), WithReadiness(ready))
+	}()
+	waitReady(t, ready)
+
+	resp, err := client.Get("https://" + l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "true" {
+		t.Errorf("got %q, expected %q", body, "true")
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	// A TLSConfig without certificates isn't served as plaintext.
+	l = testListener(t)
+	defer l.Close()
+	srv = &http.Server{TLSConfig: &tls.Config{}}
+	if err := Serve(context.Background(), srv, WithListener(l)); err == nil {
+		t.Error("expected an error for a TLSConfig without certificates")
+	}
+}
+
+func TestServe_ListenError(t *testing.T) {
+	l := testListener(t)
+	defer l.Close()
+
+	hooked := false
+	ready := &Readiness{}
+	srv := &http.Server{Addr: l.Addr().String()}
+	err := Serve(context.Background(), srv, WithReadiness(ready), WithShutdownHook(func(context.Context) error {
+		hooked = true
+		return nil
+	}))
+
+	// The bind error is returned directly, without the server ever
+	// being ready or shut down.
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "listen" {
+		t.Errorf("got %v, expected a listen *net.OpError", err)
+	}
+	if ready.Ready() {
+		t.Error("expected the server not to be ready")
+	}
+	if hooked {
+		t.Error("expected the shutdown hooks not to run")
+	}
+}
+
+func TestRunHook_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	block := make(chan struct{})
+	defer close(block)
+	err := runHook(ctx, FlushHook(func() error {
+		<-block
+		return nil
+	}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	r := &Readiness{}
+	for i, ex := range []struct {
+		ready bool
+		code  int
+		err   error
+	}{
+		{ready: false, code: http.StatusServiceUnavailable, err: ErrNotReady},
+		{ready: true, code: http.StatusOK, err: nil},
+	} {
+		r.SetReady(ex.ready)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+		if w.Code != ex.code {
+			t.Errorf("[Example %d] got %d, expected %d", i+1, w.Code, ex.code)
+		}
+		if err := r.Check(context.Background()); err != ex.err {
+			t.Errorf("[Example %d] got %v, expected %v", i+1, err, ex.err)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package iylog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy determines what an AsyncLogger does with a message
+// when its queue is full.
+type OverflowPolicy int
+
+// Supported overflow policies.
+const (
+	// Block waits for space in the queue.
+	Block OverflowPolicy = iota
+	// DropNewest drops the message being logged.
+	DropNewest
+	// DropOldest drops the oldest message in the queue to make room for
+	// the message being logged.
+	DropOldest
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "Block"
+	case DropNewest:
+		return "DropNewest"
+	case DropOldest:
+		return "DropOldest"
+	}
+	return "UNKNOWN"
+}
+
+// asyncMsg is a message queued on an AsyncLogger.
+type asyncMsg struct {
+	e   Entry
+	raw bool // e was logged using Printf rather than Log.
+}
+
+// AsyncLogger wraps a Loggable, queuing messages to be logged by a
+// separate goroutine, so that a slow Loggable does not block callers.
+//
+// The queue is bounded. When it's full, messages are handled according
+// to the AsyncLogger's OverflowPolicy, and the number of messages
+// dropped can be retrieved using Dropped.
+//
+// Messages are formatted when they're logged, but the values of any
+// fields are only rendered when the message is passed to the wrapped
+// Loggable, so should not be modified after logging.
+//
+// Flush waits for all queued messages to be logged, and Close should be
+// called on shutdown to drain the queue and stop the goroutine.
+//
+// An AsyncLogger is safe for use by multiple goroutines.
+type AsyncLogger struct {
+	dropped uint64 // accessed atomically; first for 64-bit alignment.
+
+	l      Loggable
+	queue  chan asyncMsg
+	policy OverflowPolicy
+	done   chan struct{}
+
+	mu      sync.RWMutex // held for writing when closing the queue.
+	closed  bool
+	pmu     sync.Mutex
+	pending int // messages queued or being logged.
+	drained *sync.Cond
+}
+
+// NewAsyncLogger returns a new AsyncLogger wrapping l, with a queue
+// holding up to size messages.
+//
+// NewAsyncLogger panics if size is less than 1.
+func NewAsyncLogger(l Loggable, size int, policy OverflowPolicy) *AsyncLogger {
+	if size < 1 {
+		panic("queue size must be positive")
+	}
+
+	a := &AsyncLogger{
+		l:      l,
+		queue:  make(chan asyncMsg, size),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+	a.drained = sync.NewCond(&a.pmu)
+
+	go a.run()
+	return a
+}
+
+// run logs queued messages until the queue is closed.
+func (a *AsyncLogger) run() {
+	defer close(a.done)
+	for msg := range a.queue {
+		a.log(msg)
+		a.release(1)
+	}
+}
+
+// log passes msg on to the wrapped Loggable.
+func (a *AsyncLogger) log(msg asyncMsg) {
+	if msg.raw {
+		a.l.Printf("%s", msg.e.Message)
+		return
+	}
+
+	if fl, ok := a.l.(FieldLoggable); ok {
+		fl.Log(msg.e)
+		return
+	}
+	format, args := msg.e.printf()
+	a.l.Printf(format, args...)
+}
+
+// release marks n messages as no longer pending.
+func (a *AsyncLogger) release(n int) {
+	a.pmu.Lock()
+	defer a.pmu.Unlock()
+	a.pending -= n
+	if a.pending == 0 {
+		a.drained.Broadcast()
+	}
+}
+
+// enqueue adds msg to the queue, according to the OverflowPolicy.
+// Messages logged after the AsyncLogger is closed are dropped.
+func (a *AsyncLogger) enqueue(msg asyncMsg) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		atomic.AddUint64(&a.dropped, 1)
+		return
+	}
+
+	a.pmu.Lock()
+	a.pending++
+	a.pmu.Unlock()
+
+	switch a.policy {
+	case DropNewest:
+		select {
+		case a.queue <- msg:
+		default:
+			atomic.AddUint64(&a.dropped, 1)
+			a.release(1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case a.queue <- msg:
+				return
+			default:
+			}
+
+			select {
+			case <-a.queue:
+				atomic.AddUint64(&a.dropped, 1)
+				a.release(1)
+			default:
+			}
+		}
+	default:
+		a.queue <- msg
+	}
+}
+
+// Printf queues the formatted message to be passed to the wrapped
+// Loggable's Printf.
+func (a *AsyncLogger) Printf(format string, v ...interface{}) {
+	a.enqueue(asyncMsg{e: Entry{Message: fmt.Sprintf(format, v...)}, raw: true})
+}
+
+// Log queues e to be passed to the wrapped Loggable.
+func (a *AsyncLogger) Log(e Entry) {
+	// Drop the message's arguments, so that it's rendered from the
+	// already formatted message.
+	e.format, e.args = "", nil
+	a.enqueue(asyncMsg{e: e})
+}
+
+// Level returns the Level of the wrapped Loggable.
+func (a *AsyncLogger) Level() Level { return a.l.Level() }
+
+// SetLevel sets the Level of the wrapped Loggable, if it implements
+// LevelSetter.
+func (a *AsyncLogger) SetLevel(level Level) {
+	if ls, ok := a.l.(LevelSetter); ok {
+		ls.SetLevel(level)
+	}
+}
+
+// ReportCaller determines if the wrapped Loggable is a CallerReporter
+// reporting callers, so that the call site is found before the entry is
+// queued.
+func (a *AsyncLogger) ReportCaller() bool {
+	cr, ok := a.l.(CallerReporter)
+	return ok && cr.ReportCaller()
+}
+
+// Dropped returns the number of messages dropped, either because the
+// queue was full or because they were logged after Close was called.
+func (a *AsyncLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Flush waits for all queued messages to be passed to the wrapped
+// Loggable, and then flushes the wrapped Loggable if it implements
+// Flusher.
+func (a *AsyncLogger) Flush() error {
+	a.pmu.Lock()
+	for a.pending > 0 {
+		a.drained.Wait()
+	}
+	a.pmu.Unlock()
+
+	if f, ok := a.l.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close stops the AsyncLogger accepting messages, waits for the queue
+// to drain, and flushes the wrapped Loggable. Messages logged after
+// Close is called are dropped.
+//
+// Close does not close the wrapped Loggable. It is safe to call Close
+// more than once.
+func (a *AsyncLogger) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	<-a.done
+	return a.Flush()
+}
@@ -0,0 +1,106 @@
+package iylog
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// gatedLogger is a Loggable that blocks in Printf until gate is
+// closed, recording each message it logs.
+type gatedLogger struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+
+	mu   sync.Mutex
+	msgs []string
+}
+
+func newGatedLogger() *gatedLogger {
+	return &gatedLogger{gate: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (l *gatedLogger) Printf(format string, v ...interface{}) {
+	l.once.Do(func() { close(l.started) })
+	<-l.gate
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprintf(format, v...))
+}
+
+func (l *gatedLogger) Level() Level { return DEBUG }
+
+func (l *gatedLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.msgs
+}
+
+func TestAsyncLogger_Policies(t *testing.T) {
+	examples := []struct {
+		policy  OverflowPolicy
+		exp     []string
+		dropped uint64
+	}{
+		{policy: DropNewest, exp: []string{"0", "1", "2"}, dropped: 2},
+		{policy: DropOldest, exp: []string{"0", "3", "4"}, dropped: 2},
+	}
+
+	for i, ex := range examples {
+		gl := newGatedLogger()
+		a := NewAsyncLogger(gl, 2, ex.policy)
+
+		// The first message is taken off the queue by the worker, which
+		// then blocks, leaving room for two more messages.
+		a.Printf("%s", "0")
+		<-gl.started
+		for _, msg := range []string{"1", "2", "3", "4"} {
+			a.Printf("%s", msg)
+		}
+
+		close(gl.gate)
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if obt := gl.messages(); !reflect.DeepEqual(obt, ex.exp) {
+			t.Errorf("[Example %d] "+expFmt, i, ex.exp, obt)
+		}
+		if obt := a.Dropped(); obt != ex.dropped {
+			t.Errorf("[Example %d] "+expFmt, i, ex.dropped, obt)
+		}
+	}
+}
+
+func TestAsyncLogger_Flush(t *testing.T) {
+	fl := &testFieldLogger{lvl: INFO}
+	a := NewAsyncLogger(fl, 10, Block)
+	l := NewMultiLogger(a)
+
+	l.With("k", 1).Info("a")
+	l.Debug("filtered")
+	l.Warningf("%s", "b")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fl.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(fl.entries))
+	}
+	if obt := fl.entries[0].String(); obt != "[INFO] a k=1" {
+		t.Errorf(expFmt, "[INFO] a k=1", obt)
+	}
+	if obt := fl.entries[1].Level; obt != WARNING {
+		t.Errorf(expFmt, WARNING, obt)
+	}
+
+	// Messages logged after Close are dropped.
+	a.Close()
+	l.Info("late")
+	if obt := a.Dropped(); obt != 1 {
+		t.Errorf(expFmt, 1, obt)
+	}
+}
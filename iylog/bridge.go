@@ -0,0 +1,93 @@
+package iylog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// linePrefix matches level prefixes of the forms "[ERROR] " and
+// "ERROR: ".
+var linePrefix = regexp.MustCompile(`^(?:\[([A-Za-z]+)\]|([A-Za-z]+):)\s*`)
+
+// Writer is an io.Writer that logs each line written to it through a
+// MultiLogger, allowing output written using the standard library's
+// log package to be routed through iylog, e.g.,
+//
+//	log.SetFlags(0)
+//	log.SetOutput(iylog.NewWriter(iylog.Named("stdlib"), iylog.INFO, true))
+//
+// Since the MultiLogger's Loggables typically add their own timestamps,
+// the log package's flags should usually be cleared.
+//
+// A Writer is safe for use by multiple goroutines.
+type Writer struct {
+	m     *MultiLogger
+	level Level
+	parse bool
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewWriter returns a new Writer, which logs lines at level through m.
+// If m is nil, the package-level MultiLogger is used.
+//
+// If parseLevels is true, lines starting with a level, such as
+// "[ERROR] " or "WARN: ", are logged at that level instead, with the
+// prefix removed.
+func NewWriter(m *MultiLogger, level Level, parseLevels bool) *Writer {
+	if m == nil {
+		m = std
+	}
+	return &Writer{m: m, level: level, parse: parseLevels}
+}
+
+// Write logs each complete line in p. Incomplete lines are buffered
+// until the rest of the line is written, or Flush is called.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush logs any buffered incomplete line.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.log(string(w.buf))
+		w.buf = nil
+	}
+	return nil
+}
+
+// log logs line, at the level in its prefix if there is one and the
+// Writer is parsing levels.
+func (w *Writer) log(line string) {
+	line = strings.TrimSuffix(line, "\r")
+	level := w.level
+	if w.parse {
+		if m := linePrefix.FindStringSubmatch(line); m != nil {
+			name := m[1] + m[2]
+			if strings.EqualFold(name, "WARN") {
+				name = "WARNING"
+			}
+			if l, err := ToLevel(name); err == nil {
+				level, line = l, line[len(m[0]):]
+			}
+		}
+	}
+	w.m.print(nil, level, line)
+}
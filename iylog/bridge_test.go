@@ -0,0 +1,72 @@
+package iylog
+
+import (
+	"fmt"
+	"log"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	examples := []struct {
+		parse bool
+		in    string
+		level Level
+		msg   string
+	}{
+		{parse: true, in: "plain message", level: INFO, msg: "plain message"},
+		{parse: true, in: "[ERROR] it broke", level: ERROR, msg: "it broke"},
+		{parse: true, in: "WARN: careful", level: WARNING, msg: "careful"},
+		{parse: true, in: "debug:  spaced", level: DEBUG, msg: "spaced"},
+		{parse: true, in: "note: not a level", level: INFO, msg: "note: not a level"},
+		{parse: false, in: "[ERROR] it broke", level: INFO, msg: "[ERROR] it broke"},
+	}
+
+	for i, ex := range examples {
+		fl := &testFieldLogger{lvl: TRACE}
+		lg := log.New(NewWriter(NewMultiLogger(fl), INFO, ex.parse), "", 0)
+		lg.Print(ex.in)
+
+		if len(fl.entries) != 1 {
+			t.Fatalf("[Example %d] expected 1 entry, got %d", i+1, len(fl.entries))
+		}
+		if e := fl.entries[0]; e.Level != ex.level || e.Message != ex.msg {
+			t.Errorf("[Example %d] "+expFmt, i+1, ex.level.String()+" "+ex.msg, e.Level.String()+" "+e.Message)
+		}
+	}
+}
+
+func TestWriter_PartialLines(t *testing.T) {
+	fl := &testFieldLogger{lvl: TRACE}
+	w := NewWriter(NewMultiLogger(fl), INFO, false)
+
+	w.Write([]byte("one\ntw"))
+	w.Write([]byte("o\r\nthr"))
+	if len(fl.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(fl.entries))
+	}
+
+	w.Flush()
+	if len(fl.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(fl.entries))
+	}
+	for i, exp := range []string{"one", "two", "thr"} {
+		if obt := fl.entries[i].Message; obt != exp {
+			t.Errorf("[Example %d] "+expFmt, i+1, exp, obt)
+		}
+	}
+}
+
+func TestWriter_Caller(t *testing.T) {
+	fl := &testFieldLogger{lvl: TRACE}
+	m := NewMultiLogger(fl)
+	m.SetReportCaller(true)
+
+	lg := log.New(NewWriter(m, INFO, false), "", 0)
+	lg.Print("hello") // the caller reported should be this line.
+	exp := line() - 1
+
+	v, _, _ := fieldValue(fl.entries[0].Fields, CallerKey)
+	if want := fmt.Sprintf("iylog/bridge_test.go:%d", exp); v != want {
+		t.Errorf(expFmt, want, v)
+	}
+}
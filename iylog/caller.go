@@ -0,0 +1,139 @@
+package iylog
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Keys of the fields attached by a MultiLogger reporting callers, and
+// by CapturePanic.
+const (
+	CallerKey = "caller" // file and line, e.g., "app/main.go:12".
+	FuncKey   = "func"   // function, e.g., "main.main".
+	StackKey  = "stack"  // goroutine stack trace.
+)
+
+// pkgPrefix prefixes the names of all functions in this package.
+var pkgPrefix = reflect.TypeOf(Level(0)).PkgPath() + "."
+
+// caller returns the first frame on the calling goroutine's stack
+// outside of this package, which is the call site of the logging
+// function. Walking the stack, rather than skipping a fixed number of
+// frames, means the call site is found however deeply the logging call
+// is nested within the package.
+func caller() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !internalFrame(f) {
+			return f, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// internalFrame determines if f belongs to this package, or to the
+// standard library's log and log/slog packages, which log through this
+// package using a Writer or SlogHandler. Frames in the runtime are also
+// skipped, so that when CapturePanic is deferred the call site of the
+// panic is found, rather than runtime.gopanic. Frames in the package's
+// tests are not considered internal.
+func internalFrame(f runtime.Frame) bool {
+	for _, prefix := range []string{"log.", "log/slog.", "runtime."} {
+		if strings.HasPrefix(f.Function, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(f.Function, pkgPrefix) && !strings.HasSuffix(f.File, "_test.go")
+}
+
+// shortFile returns the frame's file, trimmed to the file's directory
+// and name, and line, e.g., "iylog/log.go:12".
+func shortFile(f runtime.Frame) string {
+	file := f.File
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		if j := strings.LastIndex(file[:i], "/"); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(f.Line)
+}
+
+// SetReportCaller sets whether the MultiLogger attaches the call site
+// of each logging function to the message, as the CallerKey and FuncKey
+// fields, e.g.,
+//
+//	[ERROR] boom caller=app/main.go:12 func=main.main
+//
+// The call site is found by walking the stack to the first function
+// outside of this package, so it is correct whether a package-level
+// function or a MultiLogger method was called.
+//
+// Calling SetReportCaller on a child MultiLogger sets it on the
+// MultiLogger it was created from.
+func (m *MultiLogger) SetReportCaller(report bool) {
+	r := m.root()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reportCaller = report
+}
+
+// SetReportCaller sets whether the package-level MultiLogger reports
+// callers.
+func SetReportCaller(report bool) {
+	std.SetReportCaller(report)
+}
+
+// A CallerReporter is a Loggable which reports the call site of each
+// entry, such as a JSONLogger. A MultiLogger finds the call site of
+// each entry passed to a CallerReporter whose ReportCaller method
+// returns true, as the Entry's Caller, even if SetReportCaller is not
+// enabled.
+type CallerReporter interface {
+	ReportCaller() bool
+}
+
+// reportsCaller determines if any of the MultiLogger's Loggables
+// listening at level are CallerReporters reporting callers. The caller
+// must hold the read lock.
+func (m *MultiLogger) reportsCaller(level Level) bool {
+	for _, l := range m.loggables {
+		if cr, ok := l.(CallerReporter); ok && level >= l.Level() && cr.ReportCaller() {
+			return true
+		}
+	}
+	return false
+}
+
+// callerFields returns the fields describing the call site of the
+// logging function.
+func callerFields() []Field {
+	f, ok := caller()
+	if !ok {
+		return nil
+	}
+	return []Field{
+		{Key: CallerKey, Value: shortFile(f)},
+		{Key: FuncKey, Value: f.Function},
+	}
+}
+
+// fieldValue returns the value of the first field with key k, and
+// fields without it.
+func fieldValue(fields []Field, k string) (string, []Field, bool) {
+	for i, f := range fields {
+		if f.Key == k {
+			rest := make([]Field, 0, len(fields)-1)
+			rest = append(append(rest, fields[:i]...), fields[i+1:]...)
+			return fmt.Sprint(f.Value), rest, true
+		}
+	}
+	return "", fields, false
+}
@@ -0,0 +1,163 @@
+package iylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// line returns the line it was called from.
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l
+}
+
+func TestMultiLogger_SetReportCaller(t *testing.T) {
+	fl := &testFieldLogger{lvl: DEBUG}
+	l := NewMultiLogger(fl)
+	l.SetReportCaller(true)
+
+	// The call site is found through methods, package-level functions,
+	// and child loggers.
+	defer func(m *MultiLogger) { std = m }(std)
+	std = l
+
+	l.Info("a")
+	l1 := line() - 1
+	Errorf("b")
+	l2 := line() - 1
+	With("k", "v").Debug("c")
+	l3 := line() - 1
+
+	if len(fl.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(fl.entries))
+	}
+
+	for i, ln := range []int{l1, l2, l3} {
+		c, _, _ := fieldValue(fl.entries[i].Fields, CallerKey)
+		if exp := fmt.Sprintf("iylog/caller_test.go:%d", ln); c != exp {
+			t.Errorf("[Example %d] "+expFmt, i, exp, c)
+		}
+
+		f, _, _ := fieldValue(fl.entries[i].Fields, FuncKey)
+		if exp := pkgPrefix + "TestMultiLogger_SetReportCaller"; f != exp {
+			t.Errorf("[Example %d] "+expFmt, i, exp, f)
+		}
+	}
+}
+
+func TestJSONLogger_ReportedCaller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewMultiLogger(NewAsyncLogger(NewJSONLogger(buf, DEBUG), 1, Block))
+	l.SetReportCaller(true)
+
+	l.Info("a")
+	ln := line() - 1
+	l.Flush()
+
+	var obt map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &obt); err != nil {
+		t.Fatal(err)
+	}
+
+	// The caller reported by the MultiLogger is used, even though the
+	// JSONLogger logs from another goroutine.
+	if exp := fmt.Sprintf("iylog/caller_test.go:%d", ln); obt["caller"] != exp {
+		t.Errorf(expFmt, exp, obt["caller"])
+	}
+	if _, ok := obt["fields.caller"]; ok {
+		t.Error("caller field duplicated")
+	}
+}
+
+func TestJSONLogger_Caller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fl := &testFieldLogger{lvl: DEBUG}
+	l := NewMultiLogger(NewAsyncLogger(NewJSONLogger(buf, DEBUG), 1, Block), fl)
+
+	l.Info("a")
+	exp := fmt.Sprintf("iylog/caller_test.go:%d", line()-1)
+	l.Flush()
+
+	var obt map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &obt); err != nil {
+		t.Fatal(err)
+	}
+
+	// The call site is written without SetReportCaller, even behind an
+	// AsyncLogger, rather than the AsyncLogger's goroutine.
+	if c := obt["caller"]; c != exp {
+		t.Errorf(expFmt, exp, c)
+	}
+
+	// Other Loggables don't receive caller fields.
+	if len(fl.entries) != 1 || len(fl.entries[0].Fields) != 0 {
+		t.Errorf(expFmt, "no fields", fl.entries)
+	}
+}
+
+func TestJSONLogger_NoCaller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fl := &testFieldLogger{lvl: DEBUG}
+	l := NewMultiLogger(NewJSONLogger(buf, DEBUG, WithCallerKey("")), fl)
+
+	l.Info("a")
+
+	// Without a caller key, no call site is found.
+	if strings.Contains(buf.String(), "caller") {
+		t.Errorf("expected no caller, got %s", buf.String())
+	}
+	if len(fl.entries) != 1 || fl.entries[0].Caller != "" {
+		t.Errorf(expFmt, "no caller", fl.entries)
+	}
+}
+
+func TestCapturePanic_Stack(t *testing.T) {
+	fl := &testFieldLogger{lvl: ERROR}
+	l := NewMultiLogger(fl)
+
+	func() {
+		defer func() { recover() }()
+		defer l.CapturePanic()
+		panic("test")
+	}()
+
+	if len(fl.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(fl.entries))
+	}
+
+	stack, _, ok := fieldValue(fl.entries[0].Fields, StackKey)
+	if !ok || !strings.Contains(stack, "TestCapturePanic_Stack") {
+		t.Errorf("expected stack trace, got %q", stack)
+	}
+}
+
+func TestMultiLogger_CapturePanic_ReportCaller(t *testing.T) {
+	fl := &testFieldLogger{lvl: DEBUG}
+	l := NewMultiLogger(fl)
+	l.SetReportCaller(true)
+
+	var ln int
+	func() {
+		defer func() { recover() }()
+		defer l.CapturePanic()
+
+		ln = line() + 1
+		panic("boom")
+	}()
+
+	if len(fl.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(fl.entries))
+	}
+	c, _, _ := fieldValue(fl.entries[0].Fields, CallerKey)
+	if exp := fmt.Sprintf("iylog/caller_test.go:%d", ln); c != exp {
+		t.Errorf(expFmt, exp, c)
+	}
+	f, _, _ := fieldValue(fl.entries[0].Fields, FuncKey)
+	if exp := pkgPrefix + "TestMultiLogger_CapturePanic_ReportCaller.func1"; f != exp {
+		t.Errorf(expFmt, exp, f)
+	}
+}
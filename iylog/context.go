@@ -0,0 +1,209 @@
+package iylog
+
+import "context"
+
+// ctxKey is the type of the keys iylog stores in a context.Context.
+type ctxKey int
+
+const (
+	loggerKey ctxKey = iota // *MultiLogger stored by NewContext.
+	fieldsKey               // []Field stored by ContextWithFields.
+)
+
+// An Extractor returns fields to attach to messages logged with a
+// context.Context, e.g., a request ID or trace ID carried by the
+// context.
+type Extractor func(ctx context.Context) []Field
+
+// NewContext returns a copy of ctx carrying m, which can be retrieved
+// using FromContext.
+func NewContext(ctx context.Context, m *MultiLogger) context.Context {
+	return context.WithValue(ctx, loggerKey, m)
+}
+
+// FromContext returns the MultiLogger carried by ctx, or the
+// package-level MultiLogger if ctx does not carry one.
+func FromContext(ctx context.Context) *MultiLogger {
+	if m, ok := ctx.Value(loggerKey).(*MultiLogger); ok {
+		return m
+	}
+	return std
+}
+
+// ContextWithFields returns a copy of ctx carrying the provided fields,
+// in addition to any fields already carried by ctx. Fields are provided
+// in the same way as for With.
+//
+// Fields carried by a context are attached to messages logged using
+// the Ctx variants of the logging functions, such as InfoCtx.
+func ContextWithFields(ctx context.Context, keyvals ...interface{}) context.Context {
+	current, _ := ctx.Value(fieldsKey).([]Field)
+	fields := make([]Field, 0, len(current)+len(keyvals)/2)
+	fields = append(append(fields, current...), toFields(keyvals)...)
+	return context.WithValue(ctx, fieldsKey, fields)
+}
+
+// RegisterExtractor registers e with the MultiLogger. The fields
+// returned by e are attached to every message logged using one of the
+// Ctx variants of the logging functions.
+//
+// Calling RegisterExtractor on a child MultiLogger registers e with
+// the MultiLogger it was created from.
+func (m *MultiLogger) RegisterExtractor(e Extractor) {
+	r := m.root()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extractors = append(r.extractors, e)
+}
+
+// RegisterExtractor registers e with the package-level MultiLogger.
+func RegisterExtractor(e Extractor) {
+	std.RegisterExtractor(e)
+}
+
+// contextFields returns fields followed by the fields carried by ctx
+// and those returned by the registered Extractors. The caller must hold
+// the read lock.
+func (m *MultiLogger) contextFields(ctx context.Context, fields []Field) []Field {
+	current, _ := ctx.Value(fieldsKey).([]Field)
+	if len(current) == 0 && len(m.extractors) == 0 {
+		return fields
+	}
+
+	all := make([]Field, 0, len(fields)+len(current))
+	all = append(append(all, fields...), current...)
+	for _, e := range m.extractors {
+		all = append(all, e(ctx)...)
+	}
+	return all
+}
+
+// ErrorfCtx prints to all loggers with a level of ERROR or above,
+// attaching any fields carried by ctx.
+func (m *MultiLogger) ErrorfCtx(ctx context.Context, format string, v ...interface{}) {
+	m.prntf(ctx, ERROR, format, v...)
+}
+
+// ErrorfCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// ERROR or above.
+func ErrorfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).ErrorfCtx(ctx, format, v...)
+}
+
+// ErrorCtx prints to all loggers with a level of ERROR or above,
+// attaching any fields carried by ctx.
+func (m *MultiLogger) ErrorCtx(ctx context.Context, v ...interface{}) {
+	m.print(ctx, ERROR, v...)
+}
+
+// ErrorCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// ERROR or above.
+func ErrorCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).ErrorCtx(ctx, v...)
+}
+
+// WarningfCtx prints to all loggers with a level of WARNING or above,
+// attaching any fields carried by ctx.
+func (m *MultiLogger) WarningfCtx(ctx context.Context, format string, v ...interface{}) {
+	m.prntf(ctx, WARNING, format, v...)
+}
+
+// WarningfCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// WARNING or above.
+func WarningfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).WarningfCtx(ctx, format, v...)
+}
+
+// WarningCtx prints to all loggers with a level of WARNING or above,
+// attaching any fields carried by ctx.
+func (m *MultiLogger) WarningCtx(ctx context.Context, v ...interface{}) {
+	m.print(ctx, WARNING, v...)
+}
+
+// WarningCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// WARNING or above.
+func WarningCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).WarningCtx(ctx, v...)
+}
+
+// InfofCtx prints to all loggers with a level of INFO or above,
+// attaching any fields carried by ctx.
+func (m *MultiLogger) InfofCtx(ctx context.Context, format string, v ...interface{}) {
+	m.prntf(ctx, INFO, format, v...)
+}
+
+// InfofCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// INFO or above.
+func InfofCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).InfofCtx(ctx, format, v...)
+}
+
+// InfoCtx prints to all loggers with a level of INFO or above,
+// attaching any fields carried by ctx.
+func (m *MultiLogger) InfoCtx(ctx context.Context, v ...interface{}) {
+	m.print(ctx, INFO, v...)
+}
+
+// InfoCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// INFO or above.
+func InfoCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).InfoCtx(ctx, v...)
+}
+
+// DebugfCtx prints to all loggers with a level of DEBUG, attaching any
+// fields carried by ctx.
+func (m *MultiLogger) DebugfCtx(ctx context.Context, format string, v ...interface{}) {
+	m.prntf(ctx, DEBUG, format, v...)
+}
+
+// DebugfCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// DEBUG.
+func DebugfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).DebugfCtx(ctx, format, v...)
+}
+
+// DebugCtx prints to all loggers with a level of DEBUG or above,
+// attaching any fields carried by ctx.
+func (m *MultiLogger) DebugCtx(ctx context.Context, v ...interface{}) {
+	m.print(ctx, DEBUG, v...)
+}
+
+// DebugCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// DEBUG or above.
+func DebugCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).DebugCtx(ctx, v...)
+}
+
+// TracefCtx prints to all loggers with a level of TRACE, attaching any
+// fields carried by ctx.
+func (m *MultiLogger) TracefCtx(ctx context.Context, format string, v ...interface{}) {
+	m.prntf(ctx, TRACE, format, v...)
+}
+
+// TracefCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// TRACE.
+func TracefCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).TracefCtx(ctx, format, v...)
+}
+
+// TraceCtx prints to all loggers with a level of TRACE, attaching any
+// fields carried by ctx.
+func (m *MultiLogger) TraceCtx(ctx context.Context, v ...interface{}) {
+	m.print(ctx, TRACE, v...)
+}
+
+// TraceCtx prints to all loggers registered within the MultiLogger
+// carried by ctx, or the iylog package standard logger, with a level of
+// TRACE.
+func TraceCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).TraceCtx(ctx, v...)
+}
@@ -0,0 +1,62 @@
+package iylog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type testCtxKey struct{}
+
+func TestMultiLogger_Ctx(t *testing.T) {
+	tl := &testLogger{buf: &bytes.Buffer{}, lvl: DEBUG}
+	l := NewMultiLogger(tl)
+	l.RegisterExtractor(func(ctx context.Context) []Field {
+		if v, ok := ctx.Value(testCtxKey{}).(string); ok {
+			return []Field{{Key: "trace_id", Value: v}}
+		}
+		return nil
+	})
+
+	ctx := ContextWithFields(context.Background(), "request_id", "a3f9")
+	ctx = ContextWithFields(ctx, "tenant", "acme")
+	ctx = context.WithValue(ctx, testCtxKey{}, "t1")
+
+	l.With("k", "v").InfofCtx(ctx, "hello %s", "world")
+	exp := "[INFO] hello world k=v request_id=a3f9 tenant=acme trace_id=t1"
+	if obt := tl.buf.String(); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+	tl.buf.Reset()
+
+	// Non-Ctx variants ignore context fields and extractors.
+	l.Info("plain")
+	if obt := tl.buf.String(); obt != "[INFO] plain" {
+		t.Errorf(expFmt, "[INFO] plain", obt)
+	}
+	tl.buf.Reset()
+
+	// Extractors are only consulted when something will be logged.
+	tl.lvl = ERROR
+	l.RegisterExtractor(func(ctx context.Context) []Field {
+		t.Error("extractor called for filtered message")
+		return nil
+	})
+	l.DebugCtx(ctx, "filtered")
+}
+
+func TestContext_Logger(t *testing.T) {
+	tl := &testLogger{buf: &bytes.Buffer{}, lvl: DEBUG}
+	l := NewMultiLogger(tl)
+
+	if FromContext(context.Background()) != std {
+		t.Error("expected package-level MultiLogger from empty context")
+	}
+
+	ctx := NewContext(context.Background(), l.With("svc", "billing"))
+	WarningCtx(ctx, "a", "b")
+	exp := "[WARNING] a b svc=billing"
+	if obt := tl.buf.String(); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+}
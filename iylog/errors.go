@@ -0,0 +1,100 @@
+package iylog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// maxCauses limits how far an error chain is followed.
+const maxCauses = 32
+
+// A LogFielder is an error carrying structured information, which is
+// attached to messages the error is logged in, such as an iyhttp.Error's
+// status code.
+type LogFielder interface {
+	LogFields() map[string]interface{}
+}
+
+// errorFields returns fields describing the errors in v, for messages
+// logged at ERROR or above.
+//
+// Each error's chain is unwrapped, and the message and concrete type of
+// each error in the chain are attached, along with any fields provided
+// by errors implementing LogFielder, e.g., for an error wrapping an
+// iyhttp.Error:
+//
+//	error=loading user: not found error_type=*fmt.wrapError
+//	error.1=not found error.1_type=iyhttp.Error error.1.status_code=404
+//
+// The second error in v is described with the prefix "error2", and so
+// on.
+func errorFields(v []interface{}) []Field {
+	var fields []Field
+	n := 0
+	for _, arg := range v {
+		err, ok := arg.(error)
+		if !ok || isNil(err) {
+			continue
+		}
+
+		n++
+		prefix := "error"
+		if n > 1 {
+			prefix += strconv.Itoa(n)
+		}
+
+		for i, cause := range causes(err) {
+			p := prefix
+			if i > 0 {
+				p += "." + strconv.Itoa(i)
+			}
+
+			fields = append(fields,
+				Field{Key: p, Value: cause.Error()},
+				Field{Key: p + "_type", Value: fmt.Sprintf("%T", cause)},
+			)
+			if lf, ok := cause.(LogFielder); ok {
+				for _, f := range Fields(lf.LogFields()).sorted() {
+					fields = append(fields, Field{Key: p + "." + f.Key, Value: f.Value})
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// causes returns err followed by the errors it wraps, depth first. Both
+// errors wrapping a single error, and those wrapping multiple errors,
+// such as those returned by errors.Join, are followed.
+func causes(err error) []error {
+	var all []error
+	var walk func(error)
+	walk = func(err error) {
+		if isNil(err) || len(all) == maxCauses {
+			return
+		}
+		all = append(all, err)
+
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range multi.Unwrap() {
+				walk(e)
+			}
+			return
+		}
+		walk(errors.Unwrap(err))
+	}
+	walk(err)
+	return all
+}
+
+// isNil determines if err is nil, or is a nil pointer, such as a nil
+// *iyhttp.Error, whose methods would panic if they were called.
+func isNil(err error) bool {
+	if err == nil {
+		return true
+	}
+	v := reflect.ValueOf(err)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
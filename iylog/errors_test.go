@@ -0,0 +1,109 @@
+package iylog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// statusError is an error implementing LogFielder.
+type statusError struct{ code int }
+
+func (e statusError) Error() string { return "not found" }
+
+func (e statusError) LogFields() map[string]interface{} {
+	return map[string]interface{}{"status_code": e.code, "context": "user 42"}
+}
+
+// ptrError is an error whose methods panic on a nil receiver.
+type ptrError struct{ msg string }
+
+func (e *ptrError) Error() string { return e.msg }
+
+func (e *ptrError) LogFields() map[string]interface{} {
+	return map[string]interface{}{"msg": e.msg}
+}
+
+func TestMultiLogger_ErrorFields(t *testing.T) {
+	fl := &testFieldLogger{lvl: DEBUG}
+	l := NewMultiLogger(fl)
+
+	err := fmt.Errorf("loading user: %w", statusError{code: 404})
+	l.Errorf("request failed: %v", err)
+	l.Error(errors.New("a"), "and", errors.Join(errors.New("b"), errors.New("c")))
+
+	// Errors below ERROR are logged as they are.
+	l.Warning(err)
+
+	if len(fl.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(fl.entries))
+	}
+
+	exp := []Field{
+		{Key: "error", Value: "loading user: not found"},
+		{Key: "error_type", Value: "*fmt.wrapError"},
+		{Key: "error.1", Value: "not found"},
+		{Key: "error.1_type", Value: "iylog.statusError"},
+		{Key: "error.1.context", Value: "user 42"},
+		{Key: "error.1.status_code", Value: 404},
+	}
+	if obt := fl.entries[0].Fields; !reflect.DeepEqual(obt, exp) {
+		t.Errorf(expFmt, exp, obt)
+	}
+
+	exp = []Field{
+		{Key: "error", Value: "a"},
+		{Key: "error_type", Value: "*errors.errorString"},
+		{Key: "error2", Value: "b\nc"},
+		{Key: "error2_type", Value: "*errors.joinError"},
+		{Key: "error2.1", Value: "b"},
+		{Key: "error2.1_type", Value: "*errors.errorString"},
+		{Key: "error2.2", Value: "c"},
+		{Key: "error2.2_type", Value: "*errors.errorString"},
+	}
+	if obt := fl.entries[1].Fields; !reflect.DeepEqual(obt, exp) {
+		t.Errorf(expFmt, exp, obt)
+	}
+
+	if obt := fl.entries[2].Fields; len(obt) != 0 {
+		t.Errorf(expFmt, "no fields", obt)
+	}
+}
+
+func TestMultiLogger_ErrorFields_NilPointer(t *testing.T) {
+	fl := &testFieldLogger{lvl: DEBUG}
+	l := NewMultiLogger(fl)
+
+	// Nil pointers are logged as they are, without any fields.
+	var err *ptrError
+	l.Error("failed", err)
+	l.Error(fmt.Errorf("loading: %w", err))
+
+	if len(fl.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(fl.entries))
+	}
+	if obt := fl.entries[0].String(); obt != "[ERROR] failed <nil>" {
+		t.Errorf(expFmt, "[ERROR] failed <nil>", obt)
+	}
+	if obt := fl.entries[0].Fields; len(obt) != 0 {
+		t.Errorf(expFmt, "no fields", obt)
+	}
+
+	exp := []Field{
+		{Key: "error", Value: "loading: <nil>"},
+		{Key: "error_type", Value: "*fmt.wrapError"},
+	}
+	if obt := fl.entries[1].Fields; !reflect.DeepEqual(obt, exp) {
+		t.Errorf(expFmt, exp, obt)
+	}
+
+	// They're encoded as null in JSON.
+	buf := &bytes.Buffer{}
+	NewJSONLogger(buf, DEBUG).Log(Entry{Level: ERROR, Message: "failed", Fields: []Field{{Key: "err", Value: err}}})
+	if obt := buf.String(); !strings.Contains(obt, `"err":null`) {
+		t.Errorf(expFmt, `"err":null`, obt)
+	}
+}
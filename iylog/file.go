@@ -0,0 +1,347 @@
+package iylog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is the layout of the timestamp in backup file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// FileLogger is a Logger that writes to a file, rotating the file when
+// it reaches a maximum size or has been open for a given interval.
+//
+// When the file is rotated it's renamed to a backup, with a timestamp
+// inserted before the extension, e.g., "app-2016-01-02T15-04-05.000000000.log",
+// and a new file is created in its place. Backups can optionally be
+// compressed with gzip, and the number of backups kept can be limited.
+//
+// If the file can't be opened again after it's rotated or reopened,
+// writes return the error, and opening it is retried on each write.
+//
+// FileLogger implements io.WriteCloser, so it can also be used as the
+// output of other writers.
+//
+// A FileLogger is safe for use by multiple goroutines.
+type FileLogger struct {
+	*Logger
+
+	path      string
+	maxSize   int64
+	interval  time.Duration
+	backups   int
+	compress  bool
+	sighup    bool
+	formatter Formatter
+	now       func() time.Time
+
+	mu       sync.Mutex
+	f        *os.File // nil if opening the file failed.
+	closed   bool
+	size     int64
+	opened   time.Time
+	wg       sync.WaitGroup // tracks compression and removal of backups.
+	bgmu     sync.Mutex     // serialises compression and removal of backups.
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// FileOption is a functional option for the FileLogger type.
+type FileOption func(*FileLogger)
+
+// WithMaxSize sets the size in bytes a file may reach before it is
+// rotated.
+func WithMaxSize(n int64) FileOption {
+	return func(l *FileLogger) {
+		l.maxSize = n
+	}
+}
+
+// WithRotateInterval sets how long a file is written to before it is
+// rotated.
+func WithRotateInterval(d time.Duration) FileOption {
+	return func(l *FileLogger) {
+		l.interval = d
+	}
+}
+
+// WithMaxBackups sets the number of backups to keep. Older backups are
+// removed when the file is rotated.
+func WithMaxBackups(n int) FileOption {
+	return func(l *FileLogger) {
+		l.backups = n
+	}
+}
+
+// WithCompression sets whether backups are compressed with gzip.
+func WithCompression(compress bool) FileOption {
+	return func(l *FileLogger) {
+		l.compress = compress
+	}
+}
+
+// WithReopenOnSIGHUP sets whether the file is reopened when the process
+// receives a SIGHUP, for use with external rotation tools.
+func WithReopenOnSIGHUP(reopen bool) FileOption {
+	return func(l *FileLogger) {
+		l.sighup = reopen
+	}
+}
+
+// WithFormatter sets the Formatter used to format messages written to
+// the file.
+func WithFormatter(f Formatter) FileOption {
+	return func(l *FileLogger) {
+		l.formatter = f
+	}
+}
+
+// NewFileLogger returns a new FileLogger that appends to the file at
+// path, creating it if necessary.
+//
+// By default the file is never rotated, and all backups are kept.
+func NewFileLogger(path string, level Level, options ...FileOption) (*FileLogger, error) {
+	l := &FileLogger{path: path, now: time.Now, stop: make(chan struct{})}
+
+	// Apply any options.
+	for _, option := range options {
+		option(l)
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	l.Logger = NewFormattedLogger(l, level, l.formatter)
+
+	if l.sighup {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGHUP)
+		go func() {
+			defer signal.Stop(c)
+			for {
+				select {
+				case <-c:
+					if err := l.Reopen(); err != nil {
+						std.Errorf("reopening %v: %v", l.path, err)
+					}
+				case <-l.stop:
+					return
+				}
+			}
+		}()
+	}
+	return l, nil
+}
+
+// open opens the file for appending. The caller must hold the lock.
+func (l *FileLogger) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.f, l.size, l.opened = f, fi.Size(), l.now()
+	return nil
+}
+
+// ensureOpen opens the file again if opening it failed after it was
+// rotated or reopened. The caller must hold the lock.
+func (l *FileLogger) ensureOpen() error {
+	if l.closed {
+		return os.ErrClosed
+	}
+	if l.f == nil {
+		return l.open()
+	}
+	return nil
+}
+
+// Write writes p to the file, first rotating the file if writing p
+// would exceed the maximum size, or the rotation interval has elapsed.
+func (l *FileLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.ensureOpen(); err != nil {
+		return 0, err
+	}
+
+	full := l.maxSize > 0 && l.size > 0 && l.size+int64(len(p)) > l.maxSize
+	expired := l.interval > 0 && l.now().Sub(l.opened) >= l.interval
+	if full || expired {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file, regardless of its size or age.
+func (l *FileLogger) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.ensureOpen(); err != nil {
+		return err
+	}
+	return l.rotate()
+}
+
+// rotate renames the file to a backup and opens a new file in its
+// place. The caller must hold the lock.
+func (l *FileLogger) rotate() error {
+	err := l.f.Close()
+	l.f = nil
+	if err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(l.path)
+	backup := strings.TrimSuffix(l.path, ext) + "-" + l.now().Format(backupTimeFormat) + ext
+	if err := os.Rename(l.path, backup); err != nil {
+		// Carry on writing to the current file.
+		if oerr := l.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+
+	if err := l.open(); err != nil {
+		return err
+	}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.bgmu.Lock()
+		defer l.bgmu.Unlock()
+
+		if l.compress {
+			if err := compressFile(backup); err != nil {
+				std.Errorf("compressing %v: %v", backup, err)
+			}
+		}
+		l.prune()
+	}()
+	return nil
+}
+
+// prune removes the oldest backups, keeping the configured number.
+func (l *FileLogger) prune() {
+	if l.backups <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(l.path)
+	prefix := strings.TrimSuffix(l.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return
+	}
+
+	var backups []string
+	for _, m := range matches {
+		ts := strings.TrimSuffix(strings.TrimSuffix(m[len(prefix):], ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, ts); err == nil {
+			backups = append(backups, m)
+		}
+	}
+
+	// Backup names sort in the order they were created.
+	sort.Strings(backups)
+	for len(backups) > l.backups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// compressFile gzips the file at path to path.gz, removing the
+// original.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Reopen closes and reopens the file. Reopen is useful when the file
+// has been moved by an external rotation tool.
+func (l *FileLogger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+
+	if l.f != nil {
+		err := l.f.Close()
+		l.f = nil
+		if err != nil {
+			return err
+		}
+	}
+	return l.open()
+}
+
+// Close closes the file, and waits for any backups to be compressed.
+func (l *FileLogger) Close() error {
+	// Stop reopening on SIGHUP, whether or not the file is open.
+	l.stopOnce.Do(func() { close(l.stop) })
+
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return os.ErrClosed
+	}
+
+	var err error
+	if l.f != nil {
+		err = l.f.Close()
+		l.f = nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	l.wg.Wait()
+	return err
+}
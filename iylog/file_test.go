@@ -0,0 +1,188 @@
+package iylog
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backups returns the backup files in dir, in the order they were
+// created.
+func backups(t *testing.T, dir string) []string {
+	matches, err := filepath.Glob(filepath.Join(dir, "app-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func TestFileLogger_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "app.log")
+
+	l, err := NewFileLogger(pth, INFO, WithMaxSize(10), WithMaxBackups(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"aaaaaa", "bbbbbb", "cccccc", "dddddd"} {
+		if _, err := l.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each write overflows the file, and only two backups are kept.
+	b := backups(t, dir)
+	if len(b) != 2 {
+		t.Fatalf("expected 2 backups, got %v", b)
+	}
+
+	for i, exp := range []string{"bbbbbb", "cccccc"} {
+		data, _ := ioutil.ReadFile(b[i])
+		if string(data) != exp {
+			t.Errorf(expFmt, exp, string(data))
+		}
+		if !strings.HasSuffix(b[i], ".log") {
+			t.Errorf("expected .log extension, got %v", b[i])
+		}
+	}
+
+	data, _ := ioutil.ReadFile(pth)
+	if string(data) != "dddddd" {
+		t.Errorf(expFmt, "dddddd", string(data))
+	}
+}
+
+func TestFileLogger_Interval(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "app.log")
+
+	now := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	l, err := NewFileLogger(pth, INFO, WithRotateInterval(time.Hour), WithCompression(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return now }
+	l.opened = now
+
+	NewMultiLogger(l).Info("first")
+	now = now.Add(time.Hour)
+	NewMultiLogger(l).Info("second")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := backups(t, dir)
+	if len(b) != 1 || !strings.HasSuffix(b[0], ".log.gz") {
+		t.Fatalf("expected 1 compressed backup, got %v", b)
+	}
+
+	f, err := os.Open(b[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := ioutil.ReadAll(gz)
+	if !strings.HasSuffix(string(data), "[INFO] first\n") {
+		t.Errorf("expected first message in backup, got %q", data)
+	}
+
+	data, _ = ioutil.ReadFile(pth)
+	if !strings.HasSuffix(string(data), "[INFO] second\n") {
+		t.Errorf("expected second message in file, got %q", data)
+	}
+}
+
+func TestFileLogger_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "app.log")
+
+	l, err := NewFileLogger(pth, INFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Write([]byte("a"))
+	if err := os.Rename(pth, pth+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	l.Write([]byte("b"))
+
+	for p, exp := range map[string]string{pth + ".1": "a", pth: "b"} {
+		data, _ := ioutil.ReadFile(p)
+		if string(data) != exp {
+			t.Errorf(expFmt, exp, string(data))
+		}
+	}
+}
+
+func TestFileLogger_ReopenError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pth := filepath.Join(dir, "app.log")
+
+	l, err := NewFileLogger(pth, INFO, WithReopenOnSIGHUP(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file can't be reopened once its directory has gone.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reopen(); err == nil {
+		t.Fatal("expected an error reopening the file")
+	}
+	if _, err := l.Write([]byte("a")); err == nil || err == os.ErrClosed {
+		t.Errorf(expFmt, "an error opening the file", err)
+	}
+
+	// Opening the file is retried on the next write.
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Write([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(pth); string(data) != "b" {
+		t.Errorf(expFmt, "b", string(data))
+	}
+
+	// Closing stops the SIGHUP handler, even if the file isn't open.
+	os.RemoveAll(dir)
+	l.Reopen()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-l.stop:
+	default:
+		t.Error("expected the SIGHUP handler to be stopped")
+	}
+	if _, err := l.Write([]byte("c")); err != os.ErrClosed {
+		t.Errorf(expFmt, os.ErrClosed, err)
+	}
+	if err := l.Close(); err != os.ErrClosed {
+		t.Errorf(expFmt, os.ErrClosed, err)
+	}
+}
@@ -0,0 +1,212 @@
+package iylog
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Formatter formats an Entry as a line of output for a Logger. Custom
+// formats can be written by implementing Formatter, or using
+// FormatterFunc.
+type Formatter interface {
+	// Format returns e formatted as a line. A trailing newline is
+	// added by the Logger if missing.
+	Format(e Entry) []byte
+}
+
+// FormatterFunc is an adapter allowing ordinary functions to be used
+// as Formatters.
+type FormatterFunc func(e Entry) []byte
+
+// Format calls f(e).
+func (f FormatterFunc) Format(e Entry) []byte { return f(e) }
+
+// levelPrefix matches the level prefix of a rendered Entry.
+var levelPrefix = regexp.MustCompile(`^\[([A-Z]+)\] `)
+
+// parseEntry returns an Entry for a message passed to Printf. If the
+// message starts with a level, such as "[INFO] ", the Entry is given
+// that level, and otherwise level.
+func parseEntry(level Level, msg string) Entry {
+	e := Entry{Time: time.Now(), Level: level, Message: msg}
+	if m := levelPrefix.FindStringSubmatch(msg); m != nil {
+		if l, err := ToLevel(m[1]); err == nil {
+			e.Level, e.Message = l, msg[len(m[0]):]
+		}
+	}
+	return e
+}
+
+// timestamp formats t using layout, or def if layout is empty. An
+// empty string is returned for the zero time.
+func timestamp(t time.Time, layout, def string, utc bool) string {
+	if t.IsZero() {
+		return ""
+	}
+	if layout == "" {
+		layout = def
+	}
+	if utc {
+		t = t.UTC()
+	}
+	return t.Format(layout)
+}
+
+// TextFormatter formats entries in the default text format, e.g.,
+//
+//	2016/01/02 15:04:05 [INFO] billing: request handled request_id=a3f9
+type TextFormatter struct {
+	// TimeLayout is the layout used to format times. The default is
+	// "2006/01/02 15:04:05", matching the standard library's
+	// log.LstdFlags.
+	TimeLayout string
+
+	// UTC formats times in UTC rather than the local time zone.
+	UTC bool
+}
+
+// Format implements the Formatter interface.
+func (f TextFormatter) Format(e Entry) []byte {
+	var buf bytes.Buffer
+	if ts := timestamp(e.Time, f.TimeLayout, "2006/01/02 15:04:05", f.UTC); ts != "" {
+		buf.WriteString(ts)
+		buf.WriteByte(' ')
+	}
+
+	fmt.Fprintf(&buf, "[%s] ", e.Level)
+	if e.Name != "" {
+		buf.WriteString(e.Name)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(e.Message)
+	for _, fl := range e.Fields {
+		fmt.Fprintf(&buf, " %s=%v", fl.Key, fl.Value)
+	}
+	return buf.Bytes()
+}
+
+// LogfmtFormatter formats entries as logfmt, e.g.,
+//
+//	time=2016-01-02T15:04:05Z level=info logger=billing msg="request handled" request_id=a3f9
+type LogfmtFormatter struct {
+	// TimeLayout is the layout used to format times. The default is
+	// time.RFC3339.
+	TimeLayout string
+
+	// UTC formats times in UTC rather than the local time zone.
+	UTC bool
+}
+
+// Format implements the Formatter interface.
+func (f LogfmtFormatter) Format(e Entry) []byte {
+	var buf bytes.Buffer
+	if ts := timestamp(e.Time, f.TimeLayout, time.RFC3339, f.UTC); ts != "" {
+		writeLogfmt(&buf, "time", ts)
+	}
+	writeLogfmt(&buf, "level", strings.ToLower(e.Level.String()))
+	if e.Name != "" {
+		writeLogfmt(&buf, "logger", e.Name)
+	}
+	writeLogfmt(&buf, "msg", e.Message)
+	for _, fl := range e.Fields {
+		writeLogfmt(&buf, fl.Key, fmt.Sprint(fl.Value))
+	}
+	return buf.Bytes()
+}
+
+// writeLogfmt writes a logfmt key/value pair to buf, separated from any
+// preceding pair by a space.
+func writeLogfmt(buf *bytes.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key))
+	buf.WriteByte('=')
+
+	if needsQuote(value) {
+		buf.WriteString(strconv.Quote(value))
+		return
+	}
+	buf.WriteString(value)
+}
+
+// needsQuote determines if a logfmt value must be quoted.
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ANSI escape sequences used by ColorFormatter.
+const (
+	colorReset   = "\x1b[0m"
+	colorGray    = "\x1b[90m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorCyan    = "\x1b[36m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+// levelColor returns the escape sequence used to colour level.
+func levelColor(level Level) string {
+	switch {
+	case level >= PANIC:
+		return colorBoldRed
+	case level >= ERROR:
+		return colorRed
+	case level >= WARNING:
+		return colorYellow
+	case level >= INFO:
+		return colorBlue
+	}
+	return colorGray
+}
+
+// ColorFormatter formats entries for reading on a terminal, colouring
+// levels and field keys with ANSI escape sequences, e.g.,
+//
+//	15:04:05.000 INFO    billing: request handled request_id=a3f9
+type ColorFormatter struct {
+	// TimeLayout is the layout used to format times. The default is
+	// "15:04:05.000".
+	TimeLayout string
+
+	// UTC formats times in UTC rather than the local time zone.
+	UTC bool
+}
+
+// Format implements the Formatter interface.
+func (f ColorFormatter) Format(e Entry) []byte {
+	var buf bytes.Buffer
+	if ts := timestamp(e.Time, f.TimeLayout, "15:04:05.000", f.UTC); ts != "" {
+		fmt.Fprintf(&buf, "%s%s%s ", colorGray, ts, colorReset)
+	}
+
+	fmt.Fprintf(&buf, "%s%-7s%s ", levelColor(e.Level), e.Level, colorReset)
+	if e.Name != "" {
+		buf.WriteString(e.Name)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(e.Message)
+	for _, fl := range e.Fields {
+		fmt.Fprintf(&buf, " %s%s%s=%v", colorCyan, fl.Key, colorReset, fl.Value)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,92 @@
+package iylog
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// formatEntry is used to check the output of the built-in Formatters.
+var formatEntry = Entry{
+	Time:    time.Date(2016, 1, 2, 15, 4, 5, 0, time.FixedZone("EST", -5*60*60)),
+	Level:   WARNING,
+	Name:    "billing",
+	Message: "request handled",
+	Fields:  []Field{{Key: "request_id", Value: "a3f9"}, {Key: "path", Value: "/a b"}},
+}
+
+func TestFormatters(t *testing.T) {
+	examples := []struct {
+		f   Formatter
+		exp string
+	}{
+		{
+			f:   TextFormatter{},
+			exp: "2016/01/02 15:04:05 [WARNING] billing: request handled request_id=a3f9 path=/a b",
+		},
+		{
+			f:   TextFormatter{TimeLayout: time.RFC3339, UTC: true},
+			exp: "2016-01-02T20:04:05Z [WARNING] billing: request handled request_id=a3f9 path=/a b",
+		},
+		{
+			f:   LogfmtFormatter{},
+			exp: `time=2016-01-02T15:04:05-05:00 level=warning logger=billing msg="request handled" request_id=a3f9 path="/a b"`,
+		},
+		{
+			f:   LogfmtFormatter{UTC: true},
+			exp: `time=2016-01-02T20:04:05Z level=warning logger=billing msg="request handled" request_id=a3f9 path="/a b"`,
+		},
+		{
+			f:   ColorFormatter{},
+			exp: "\x1b[90m15:04:05.000\x1b[0m \x1b[33mWARNING\x1b[0m billing: request handled \x1b[36mrequest_id\x1b[0m=a3f9 \x1b[36mpath\x1b[0m=/a b",
+		},
+	}
+
+	for i, ex := range examples {
+		if obt := string(ex.f.Format(formatEntry)); obt != ex.exp {
+			t.Errorf("[Example %d] "+expFmt, i+1, ex.exp, obt)
+		}
+	}
+}
+
+func TestLogfmtFormatter_Quoting(t *testing.T) {
+	e := Entry{Level: INFO, Message: `say "hi"`, Fields: []Field{
+		{Key: "empty", Value: ""},
+		{Key: "a key", Value: "a=b"},
+		{Key: "n", Value: 42},
+	}}
+
+	exp := `level=info msg="say \"hi\"" empty="" a_key="a=b" n=42`
+	if obt := string(LogfmtFormatter{}.Format(e)); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+}
+
+func TestLogger_Log(t *testing.T) {
+	// Without a Formatter, entries are written as they were by Printf.
+	buf := &bytes.Buffer{}
+	NewMultiLogger(NewLogger(log.New(buf, "", 0), DEBUG)).Named("billing").With("k", "v").Infof("%d%%", 100)
+	if exp := "[INFO] billing: 100% k=v\n"; buf.String() != exp {
+		t.Errorf(expFmt, exp, buf.String())
+	}
+}
+
+func TestNewFormattedLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := FormatterFunc(func(e Entry) []byte {
+		return []byte(strings.ToUpper(e.Level.String()[:1] + " " + e.Message))
+	})
+	l := NewFormattedLogger(buf, INFO, f)
+
+	m := NewMultiLogger(l)
+	m.Debug("ignored")
+	m.Error("boom")
+	l.Printf("[WARNING] %s", "legacy")
+	l.Printf("plain")
+
+	if exp := "E BOOM\nW LEGACY\nI PLAIN\n"; buf.String() != exp {
+		t.Errorf(expFmt, exp, buf.String())
+	}
+}
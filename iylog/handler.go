@@ -0,0 +1,173 @@
+package iylog
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/incisively/goiy/iytime"
+)
+
+// levelState is the body of requests to, and responses from, the
+// handler returned by LevelHandler.
+type levelState struct {
+	Level       string           `json:"level"`
+	RevertAfter *iytime.Duration `json:"revert_after,omitempty"`
+	RevertAt    *time.Time       `json:"revert_at,omitempty"`
+}
+
+// levelHandler implements the handler returned by LevelHandler.
+type levelHandler struct {
+	m *MultiLogger
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	revertAt time.Time
+	previous []levelSetting // levels to revert to.
+}
+
+// levelSetting records the level of a LevelSetter.
+type levelSetting struct {
+	l     LevelSetter
+	level Level
+}
+
+// LevelHandler returns an http.Handler for reading and changing the
+// levels of m's Loggables at runtime, suitable for mounting on an
+// administrative mux.
+//
+// A GET request responds with the lowest level any of m's Loggables are
+// listening at, e.g.,
+//
+//	{"level": "INFO"}
+//
+// A PUT request sets the level of all of m's Loggables that implement
+// LevelSetter. If a revert_after duration is provided, the Loggables
+// are returned to their previous levels once it has elapsed, e.g.,
+//
+//	{"level": "DEBUG", "revert_after": "10m"}
+//
+// While a revert is pending, responses include the time it's due, as
+// revert_at. A PUT without revert_after cancels any pending revert.
+func LevelHandler(m *MultiLogger) http.Handler {
+	return &levelHandler{m: m}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "PUT":
+		var state levelState
+		if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+			http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		level, err := ToLevel(state.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var d time.Duration
+		if state.RevertAfter != nil {
+			d = time.Duration(*state.RevertAfter)
+		}
+		h.set(level, d)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.state())
+}
+
+// state returns the current state of m's levels.
+func (h *levelHandler) state() levelState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := levelState{Level: h.m.minLevel().String()}
+	if h.timer != nil {
+		at := h.revertAt
+		state.RevertAt = &at
+	}
+	return state
+}
+
+// set sets the level of m's Loggables, reverting to their previous
+// levels after d, if d is positive.
+func (h *levelHandler) set(level Level, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// If a revert is already pending, keep the levels from before it
+	// was scheduled.
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	} else {
+		h.previous = h.m.levelSettings()
+	}
+
+	h.m.SetLevel(level)
+	if d <= 0 {
+		h.previous = nil
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.timer != timer {
+			return // superseded by a later request.
+		}
+
+		for _, s := range h.previous {
+			s.l.SetLevel(s.level)
+		}
+		h.timer, h.previous = nil, nil
+	})
+	h.timer, h.revertAt = timer, time.Now().Add(d)
+}
+
+// levelSettings returns the current levels of the MultiLogger's
+// LevelSetters.
+func (m *MultiLogger) levelSettings() []levelSetting {
+	r := m.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var settings []levelSetting
+	for _, l := range r.loggables {
+		if ls, ok := l.(LevelSetter); ok {
+			settings = append(settings, levelSetting{l: ls, level: ls.Level()})
+		}
+	}
+	return settings
+}
+
+// minLevel returns the lowest level any of the MultiLogger's Loggables
+// are listening at. If there are no Loggables, minLevel returns ERROR.
+func (m *MultiLogger) minLevel() Level {
+	r := m.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.loggables) == 0 {
+		return ERROR
+	}
+
+	min := r.loggables[0].Level()
+	for _, l := range r.loggables[1:] {
+		if lvl := l.Level(); lvl < min {
+			min = lvl
+		}
+	}
+	return min
+}
@@ -0,0 +1,86 @@
+package iylog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelHandler(t *testing.T) {
+	l1 := NewLogger(nil, INFO)
+	l2 := NewMemLogger()
+	l2.SetLevel(ERROR)
+	m := NewMultiLogger(l1, l2, &testLogger{lvl: WARNING})
+	h := LevelHandler(m)
+
+	do := func(method, body string) (int, levelState) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/loglevel", strings.NewReader(body)))
+
+		var state levelState
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code, state
+	}
+
+	if code, state := do("GET", ""); code != http.StatusOK || state.Level != "INFO" {
+		t.Errorf(expFmt, "200 INFO", state)
+	}
+
+	if code, _ := do("PUT", `{"level": "LOUD"}`); code != http.StatusBadRequest {
+		t.Errorf(expFmt, http.StatusBadRequest, code)
+	}
+
+	if code, _ := do("POST", ""); code != http.StatusMethodNotAllowed {
+		t.Errorf(expFmt, http.StatusMethodNotAllowed, code)
+	}
+
+	code, state := do("PUT", `{"level": "debug", "revert_after": "50ms"}`)
+	if code != http.StatusOK || state.Level != "DEBUG" || state.RevertAt == nil {
+		t.Fatalf(expFmt, "200 DEBUG with revert_at", state)
+	}
+	if l1.Level() != DEBUG || l2.Level() != DEBUG {
+		t.Errorf("expected levels to be set, got %v and %v", l1.Level(), l2.Level())
+	}
+
+	// A second change before the revert keeps the original levels.
+	do("PUT", `{"level": "WARNING", "revert_after": "50ms"}`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for l1.Level() != INFO && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if l1.Level() != INFO || l2.Level() != ERROR {
+		t.Errorf("expected levels to revert, got %v and %v", l1.Level(), l2.Level())
+	}
+
+	// A change without revert_after is permanent.
+	do("PUT", `{"level": "ERROR"}`)
+	if code, state := do("GET", ""); code != http.StatusOK || state.Level != "WARNING" || state.RevertAt != nil {
+		t.Errorf(expFmt, "200 WARNING", state)
+	}
+}
+
+func TestMultiLogger_MinLevel(t *testing.T) {
+	examples := []struct {
+		loggables []Loggable
+		exp       Level
+	}{
+		{nil, ERROR},
+		{[]Loggable{&testLogger{lvl: FATAL}}, FATAL},
+		{[]Loggable{&testLogger{lvl: FATAL}, &testLogger{lvl: PANIC}}, PANIC},
+		{[]Loggable{&testLogger{lvl: WARNING}, &testLogger{lvl: DEBUG}}, DEBUG},
+	}
+
+	for i, ex := range examples {
+		if obt := NewMultiLogger(ex.loggables...).minLevel(); obt != ex.exp {
+			t.Errorf("[Example %d] "+expFmt, i+1, ex.exp, obt)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package iylog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// journalSocket is the path of the systemd journal's native socket.
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournalLogger implements the FieldLoggable interface, sending each
+// entry to the systemd journal using its native protocol.
+//
+// The entry's message is sent as MESSAGE, its level is mapped to a
+// syslog severity and sent as PRIORITY, and the name of the logger, if
+// it was created using Named, is sent as LOGGER. Fields are sent as
+// journal fields, with keys converted to upper case and any characters
+// the journal doesn't allow replaced with underscores, e.g., the field
+// "request-id" is sent as REQUEST_ID.
+//
+// Each entry is sent in a single datagram, so entries larger than the
+// socket's maximum datagram size are discarded, along with any other
+// errors, as with a log.Logger.
+//
+// A JournalLogger is safe for use by multiple goroutines.
+type JournalLogger struct {
+	level      int32 // accessed atomically.
+	socket     string
+	identifier string
+
+	mu   sync.Mutex
+	conn net.Conn
+	buf  bytes.Buffer
+}
+
+// JournalOption is a functional option for the JournalLogger type.
+type JournalOption func(*JournalLogger)
+
+// WithJournalSocket sets the path of the journal's socket. The default
+// is "/run/systemd/journal/socket".
+func WithJournalSocket(path string) JournalOption {
+	return func(l *JournalLogger) {
+		l.socket = path
+	}
+}
+
+// WithIdentifier sets the SYSLOG_IDENTIFIER entries are sent with. The
+// default is the name of the running program.
+func WithIdentifier(id string) JournalOption {
+	return func(l *JournalLogger) {
+		l.identifier = id
+	}
+}
+
+// NewJournalLogger returns a new JournalLogger, connected to the
+// journal's socket.
+func NewJournalLogger(level Level, options ...JournalOption) (*JournalLogger, error) {
+	l := &JournalLogger{
+		level:      int32(level),
+		socket:     journalSocket,
+		identifier: filepath.Base(os.Args[0]),
+	}
+
+	// Apply any options.
+	for _, option := range options {
+		option(l)
+	}
+
+	conn, err := net.Dial("unixgram", l.socket)
+	if err != nil {
+		return nil, err
+	}
+	l.conn = conn
+	return l, nil
+}
+
+// Printf sends the formatted message at the JournalLogger's level.
+func (l *JournalLogger) Printf(format string, v ...interface{}) {
+	l.Log(Entry{Level: l.Level(), Message: fmt.Sprintf(format, v...)})
+}
+
+// Log sends e to the journal.
+func (l *JournalLogger) Log(e Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return
+	}
+
+	l.buf.Reset()
+	l.writeField("MESSAGE", e.Message)
+	l.writeField("PRIORITY", fmt.Sprint(severity(e.Level)))
+	if l.identifier != "" {
+		l.writeField("SYSLOG_IDENTIFIER", l.identifier)
+	}
+	if e.Name != "" {
+		l.writeField("LOGGER", e.Name)
+	}
+	for _, f := range e.Fields {
+		l.writeField(journalKey(f.Key), fmt.Sprint(f.Value))
+	}
+	l.conn.Write(l.buf.Bytes())
+}
+
+// writeField writes a field to the JournalLogger's buffer. Values
+// containing newlines are written using the protocol's binary
+// encoding: the key, a newline, the value's length as a little endian
+// uint64, and then the value.
+func (l *JournalLogger) writeField(k, v string) {
+	l.buf.WriteString(k)
+	if !strings.Contains(v, "\n") {
+		l.buf.WriteString("=" + v + "\n")
+		return
+	}
+
+	l.buf.WriteByte('\n')
+	binary.Write(&l.buf, binary.LittleEndian, uint64(len(v)))
+	l.buf.WriteString(v + "\n")
+}
+
+// journalKey returns k as a valid journal field name: upper case
+// letters, digits and underscores, not starting with an underscore or
+// a digit.
+func journalKey(k string) string {
+	k = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, k)
+
+	k = strings.TrimLeft(k, "_")
+	if k == "" || (k[0] >= '0' && k[0] <= '9') {
+		k = "F_" + k
+	}
+	if len(k) > 64 {
+		k = k[:64]
+	}
+	return k
+}
+
+// Level returns the Level for the JournalLogger.
+func (l *JournalLogger) Level() Level { return Level(atomic.LoadInt32(&l.level)) }
+
+// SetLevel sets the Level for the JournalLogger.
+func (l *JournalLogger) SetLevel(level Level) { atomic.StoreInt32(&l.level, int32(level)) }
+
+// Close closes the connection to the journal.
+func (l *JournalLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+
+	err := l.conn.Close()
+	l.conn = nil
+	return err
+}
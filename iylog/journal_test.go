@@ -0,0 +1,32 @@
+package iylog
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestJournalLogger(t *testing.T) {
+	srv := listenUnixgram(t)
+
+	jl, err := NewJournalLogger(INFO, WithJournalSocket(srv.LocalAddr().String()), WithIdentifier("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jl.Close()
+
+	l := NewMultiLogger(jl)
+	l.Named("billing").With("request-id", "a3f9", "_private", 1, "9lives", 2).Info("hello\nworld")
+
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len("hello\nworld")))
+	exp := "MESSAGE\n" + string(size) + "hello\nworld\n" +
+		"PRIORITY=6\n" +
+		"SYSLOG_IDENTIFIER=app\n" +
+		"LOGGER=billing\n" +
+		"REQUEST_ID=a3f9\n" +
+		"PRIVATE=1\n" +
+		"F_9LIVES=2\n"
+	if obt := readDatagram(t, srv); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+}
@@ -0,0 +1,212 @@
+package iylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JSONLogger implements the FieldLoggable interface, writing each
+// entry as a single JSON object per line, e.g.,
+//
+//	{"time":"2016-01-02T15:04:05Z","level":"INFO","logger":"billing","msg":"hello","caller":"app/main.go:12","user_id":42}
+//
+// The caller is the call site found by the MultiLogger, since the
+// JSONLogger is a CallerReporter. The JSONLogger never walks the stack
+// itself, since when wrapped by an AsyncLogger it runs on another
+// goroutine.
+//
+// The key names used for the time, level, logger name, message and
+// caller, as well as the time format, can be configured using
+// JSONOptions. Fields whose keys clash with one of these are prefixed
+// with "fields.".
+//
+// A JSONLogger is safe for use by multiple goroutines.
+type JSONLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	buf   bytes.Buffer
+	level int32 // accessed atomically.
+
+	timeKey    string
+	levelKey   string
+	nameKey    string
+	messageKey string
+	callerKey  string
+	timeFormat string
+}
+
+// JSONOption is a functional option for the JSONLogger type.
+type JSONOption func(*JSONLogger)
+
+// WithTimeKey sets the key the entry's timestamp is written under. An
+// empty key omits the timestamp.
+func WithTimeKey(k string) JSONOption {
+	return func(l *JSONLogger) {
+		l.timeKey = k
+	}
+}
+
+// WithLevelKey sets the key the entry's level is written under. An
+// empty key omits the level.
+func WithLevelKey(k string) JSONOption {
+	return func(l *JSONLogger) {
+		l.levelKey = k
+	}
+}
+
+// WithNameKey sets the key the name of the logger is written under,
+// for loggers created using Named. An empty key omits the name.
+func WithNameKey(k string) JSONOption {
+	return func(l *JSONLogger) {
+		l.nameKey = k
+	}
+}
+
+// WithMessageKey sets the key the entry's message is written under.
+// An empty key omits the message.
+func WithMessageKey(k string) JSONOption {
+	return func(l *JSONLogger) {
+		l.messageKey = k
+	}
+}
+
+// WithCallerKey sets the key the call site's file and line are
+// written under. An empty key omits the call site.
+func WithCallerKey(k string) JSONOption {
+	return func(l *JSONLogger) {
+		l.callerKey = k
+	}
+}
+
+// WithTimeFormat sets the layout, as understood by time.Time.Format,
+// used to write the entry's timestamp.
+func WithTimeFormat(layout string) JSONOption {
+	return func(l *JSONLogger) {
+		l.timeFormat = layout
+	}
+}
+
+// NewJSONLogger returns a new JSONLogger, which writes to w.
+//
+// By default entries are written with the keys "time", "level",
+// "logger", "msg" and "caller", and timestamps are formatted using time.RFC3339Nano.
+//
+// NewJSONLogger panics if w is nil.
+func NewJSONLogger(w io.Writer, level Level, options ...JSONOption) *JSONLogger {
+	if w == nil {
+		panic("io.Writer must not be nil")
+	}
+
+	l := &JSONLogger{
+		w:          w,
+		level:      int32(level),
+		timeKey:    "time",
+		levelKey:   "level",
+		nameKey:    "logger",
+		messageKey: "msg",
+		callerKey:  "caller",
+		timeFormat: time.RFC3339Nano,
+	}
+
+	// Apply any options.
+	for _, option := range options {
+		option(l)
+	}
+	return l
+}
+
+// Printf writes the formatted message as an entry at the JSONLogger's
+// level.
+func (l *JSONLogger) Printf(format string, v ...interface{}) {
+	l.Log(Entry{Level: l.Level(), Message: fmt.Sprintf(format, v...)})
+}
+
+// Log writes e as a single line of JSON.
+func (l *JSONLogger) Log(e Entry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf.Reset()
+	l.buf.WriteByte('{')
+	reserved := map[string]bool{}
+	add := func(k string, v interface{}) {
+		if k == "" {
+			return
+		}
+		reserved[k] = true
+		l.writeField(k, v)
+	}
+
+	add(l.timeKey, e.Time.Format(l.timeFormat))
+	add(l.levelKey, e.Level.String())
+	if e.Name != "" {
+		add(l.nameKey, e.Name)
+	}
+	add(l.messageKey, e.Message)
+	c := e.Caller
+	if fc, fields, ok := fieldValue(e.Fields, CallerKey); ok {
+		c, e.Fields = fc, fields
+	}
+	if c != "" {
+		add(l.callerKey, c)
+	}
+
+	for _, f := range e.Fields {
+		k := f.Key
+		if reserved[k] {
+			k = "fields." + k
+		}
+		l.writeField(k, f.Value)
+	}
+	l.buf.WriteString("}\n")
+	l.w.Write(l.buf.Bytes())
+}
+
+// writeField writes a key and value to the JSONLogger's buffer. Values
+// that cannot be encoded as JSON are written as strings.
+func (l *JSONLogger) writeField(k string, v interface{}) {
+	if l.buf.Len() > 1 {
+		l.buf.WriteByte(',')
+	}
+
+	key, _ := json.Marshal(k)
+	l.buf.Write(key)
+	l.buf.WriteByte(':')
+
+	// errors generally have no exported fields, so encode their
+	// messages instead. Nil pointers are encoded as null.
+	if err, ok := v.(error); ok {
+		if _, ok := v.(json.Marshaler); !ok {
+			if isNil(err) {
+				v = nil
+			} else {
+				v = err.Error()
+			}
+		}
+	}
+
+	val, err := json.Marshal(v)
+	if err != nil {
+		val, _ = json.Marshal(fmt.Sprint(v))
+	}
+	l.buf.Write(val)
+}
+
+// ReportCaller determines if the JSONLogger writes the call site of
+// each entry, which it does unless the caller key is empty.
+func (l *JSONLogger) ReportCaller() bool { return l.callerKey != "" }
+
+// Level returns the Level for the JSONLogger.
+func (l *JSONLogger) Level() Level { return Level(atomic.LoadInt32(&l.level)) }
+
+// SetLevel sets the Level for the JSONLogger.
+func (l *JSONLogger) SetLevel(level Level) { atomic.StoreInt32(&l.level, int32(level)) }
@@ -0,0 +1,73 @@
+package iylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewMultiLogger(NewJSONLogger(buf, INFO))
+
+	l.With("user_id", 42, "msg", "clash", "err", errors.New("boom")).Infof("hello %s", "world")
+	caller := fmt.Sprintf("iylog/json_test.go:%d", line()-1)
+	l.Debug("ignored")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %q", len(lines), buf.String())
+	}
+
+	var obt map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &obt); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := time.Parse(time.RFC3339Nano, obt["time"].(string)); err != nil {
+		t.Errorf("invalid time: %v", err)
+	}
+	delete(obt, "time")
+
+	exp := map[string]interface{}{
+		"level":      "INFO",
+		"msg":        "hello world",
+		"caller":     caller,
+		"user_id":    float64(42),
+		"fields.msg": "clash",
+		"err":        "boom",
+	}
+	if !reflect.DeepEqual(obt, exp) {
+		t.Errorf(expFmt, exp, obt)
+	}
+
+	// Keys are written in order.
+	if !strings.HasPrefix(lines[0], `{"time":`) {
+		t.Errorf("expected time first, got %s", lines[0])
+	}
+}
+
+func TestJSONLogger_Options(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewJSONLogger(buf, DEBUG,
+		WithTimeKey("ts"),
+		WithTimeFormat("2006"),
+		WithLevelKey("severity"),
+		WithNameKey("module"),
+		WithMessageKey("message"),
+		WithCallerKey(""),
+	)
+
+	now := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.Log(Entry{Time: now, Level: WARNING, Name: "billing", Message: "m", Fields: []Field{{Key: "k", Value: []int{1}}}})
+
+	exp := `{"ts":"2016","severity":"WARNING","module":"billing","message":"m","k":[1]}` + "\n"
+	if obt := buf.String(); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+}
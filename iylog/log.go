@@ -1,29 +1,47 @@
 package iylog
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime/debug"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Supported logging levels.
+// Supported logging levels. TRACE is the zero Level, below DEBUG, so
+// that the values of the other Levels don't change.
 const (
-	DEBUG Level = 1 << iota
+	TRACE Level = 0
+	DEBUG Level = 1 << (iota - 1)
 	INFO
 	WARNING
 	ERROR
+	PANIC
+	FATAL
 )
 
-var std = NewMultiLogger()
+var (
+	std = NewMultiLogger()
+
+	// exit is called by Fatal and Fatalf, and can be replaced in tests.
+	exit = os.Exit
+)
 
 // Level describes a logging level.
 type Level int
 
 func (l Level) String() string {
 	switch l {
+	case FATAL:
+		return "FATAL"
+	case PANIC:
+		return "PANIC"
 	case ERROR:
 		return "ERROR"
 	case WARNING:
@@ -32,22 +50,56 @@ func (l Level) String() string {
 		return "INFO"
 	case DEBUG:
 		return "DEBUG"
+	case TRACE:
+		return "TRACE"
 	}
 	return "UNKNOWN"
 }
 
-// ToLevel converts a string into a Level.
-func ToLevel(l string) Level {
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// Levels are encoded by name, e.g., in JSON configuration files.
+func (l Level) MarshalText() ([]byte, error) {
+	s := l.String()
+	if s == "UNKNOWN" {
+		return nil, fmt.Errorf("unknown level %d", int(l))
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface,
+// using ToLevel to convert the text into a Level.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ToLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// ToLevel converts a string into a Level. Level names are not case
+// sensitive.
+//
+// If the string is not the name of a Level, ToLevel returns DEBUG and
+// an error.
+func ToLevel(l string) (Level, error) {
 	switch strings.ToUpper(l) {
+	case "FATAL":
+		return FATAL, nil
+	case "PANIC":
+		return PANIC, nil
 	case "ERROR":
-		return ERROR
+		return ERROR, nil
 	case "WARNING":
-		return WARNING
+		return WARNING, nil
 	case "INFO":
-		return INFO
-	default:
-		return DEBUG
+		return INFO, nil
+	case "DEBUG":
+		return DEBUG, nil
+	case "TRACE":
+		return TRACE, nil
 	}
+	return DEBUG, fmt.Errorf("unknown level %q", l)
 }
 
 // A Loggable is capable of logging at a specific Level.
@@ -56,11 +108,149 @@ type Loggable interface {
 	Level() Level
 }
 
-// Logger implements the Loggable interface. A Logger wraps a
-// log.Logger with a Level.
+// A FieldLoggable is a Loggable that can receive structured log
+// entries.
+//
+// When a Loggable added to a MultiLogger implements FieldLoggable, the
+// MultiLogger calls Log rather than Printf, passing the message and any
+// fields separately. Loggables that only implement Loggable receive
+// the entry rendered as a single line, as returned by Entry.String.
+type FieldLoggable interface {
+	Loggable
+	Log(e Entry)
+}
+
+// A LevelSetter is a Loggable whose level can be changed while it is in
+// use.
+type LevelSetter interface {
+	Loggable
+	SetLevel(level Level)
+}
+
+// A Flusher is a Loggable that buffers messages, such as an
+// AsyncLogger. Flush blocks until all buffered messages have been
+// logged.
+type Flusher interface {
+	Flush() error
+}
+
+// A Field is a key/value pair attached to a log entry.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Fields is a convenience type for providing fields as a map. Fields
+// provided as a map are attached in key order.
+type Fields map[string]interface{}
+
+// sorted returns the fields as a slice, ordered by key.
+func (f Fields) sorted() []Field {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, Field{Key: k, Value: f[k]})
+	}
+	return fields
+}
+
+// toFields converts alternating keys and values into a slice of
+// Fields. Field and Fields values may also be provided in place of a
+// key, in which case they are added as they are. A trailing key
+// without a value is given the value "(MISSING)".
+func toFields(keyvals []interface{}) []Field {
+	fields := make([]Field, 0, len(keyvals)/2)
+	for i := 0; i < len(keyvals); i++ {
+		switch k := keyvals[i].(type) {
+		case Field:
+			fields = append(fields, k)
+		case Fields:
+			fields = append(fields, k.sorted()...)
+		case map[string]interface{}:
+			fields = append(fields, Fields(k).sorted()...)
+		default:
+			f := Field{Key: fmt.Sprint(k), Value: "(MISSING)"}
+			if i+1 < len(keyvals) {
+				f.Value = keyvals[i+1]
+				i++
+			}
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// An Entry is a single log message, along with the level it was
+// logged at and any fields attached to it.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Name    string // name of the logger, if it was created using Named.
+	Message string
+	Fields  []Field
+
+	// Caller is the call site of the logging function, e.g.,
+	// "app/main.go:12", if SetReportCaller is enabled or a Loggable
+	// listening at the entry's level is a CallerReporter.
+	Caller string
+
+	// format and args are the format and arguments Message was
+	// rendered from.
+	format string
+	args   []interface{}
+}
+
+// printf returns a format and arguments that render the Entry as a
+// single line, suitable for passing to Loggable.Printf.
+func (e Entry) printf() (string, []interface{}) {
+	format, args := e.format, e.args
+	if format == "" {
+		format, args = "%s", []interface{}{e.Message}
+	}
+
+	prefix := fmt.Sprintf("[%s] ", e.Level)
+	if e.Name != "" {
+		prefix += strings.Replace(e.Name, "%", "%%", -1) + ": "
+	}
+	format = prefix + format
+	if len(e.Fields) == 0 {
+		return format, args
+	}
+
+	args = append(make([]interface{}, 0, len(args)+2*len(e.Fields)), args...)
+	for _, f := range e.Fields {
+		format += " %s=%v"
+		args = append(args, f.Key, f.Value)
+	}
+	return format, args
+}
+
+// String renders the Entry as a single line, e.g.,
+//
+//	[INFO] billing: request handled request_id=a3f9 status=200
+func (e Entry) String() string {
+	format, args := e.printf()
+	return fmt.Sprintf(format, args...)
+}
+
+// Logger implements the FieldLoggable interface. A Logger wraps a
+// log.Logger with a Level, and optionally a Formatter.
+//
+// Without a Formatter, messages are written using the log.Logger's own
+// prefix and flags, e.g.,
+//
+//	2016/01/02 15:04:05 [INFO] billing: request handled request_id=a3f9
+//
+// The Logger's Level can be changed at any time using SetLevel.
 type Logger struct {
-	logger *log.Logger
-	level  Level
+	logger    *log.Logger
+	formatter Formatter
+	level     int32 // accessed atomically.
 }
 
 // NewLogger returns a new Logger.
@@ -70,7 +260,7 @@ func NewLogger(logger *log.Logger, level Level) *Logger {
 	if logger == nil {
 		logger = log.New(os.Stderr, "", log.LstdFlags)
 	}
-	return &Logger{logger: logger, level: level}
+	return &Logger{logger: logger, level: int32(level)}
 }
 
 // NewLoggerFromWriter returns a new Logger instance using a standard
@@ -87,13 +277,52 @@ func NewLoggerFromWriter(w io.Writer, level Level) *Logger {
 	return NewLogger(log.New(w, "", log.LstdFlags), level)
 }
 
+// NewFormattedLogger returns a new Logger which writes each message to
+// w, formatted by f. The Formatter is responsible for any timestamp.
+//
+// If f is nil, NewFormattedLogger is equivalent to NewLoggerFromWriter.
+//
+// NewFormattedLogger panics if w is nil.
+func NewFormattedLogger(w io.Writer, level Level, f Formatter) *Logger {
+	if f == nil {
+		return NewLoggerFromWriter(w, level)
+	}
+	if w == nil {
+		panic("io.Writer must not be nil")
+	}
+	return &Logger{logger: log.New(w, "", 0), formatter: f, level: int32(level)}
+}
+
 // Printf calls Printf on the underlying log.Logger.
+//
+// If the Logger has a Formatter, the formatted message is written
+// using the Formatter instead. If the message starts with a level,
+// such as "[INFO] ", it is formatted at that level, and otherwise at
+// the Logger's level.
 func (l *Logger) Printf(format string, v ...interface{}) {
-	l.logger.Printf(format, v...)
+	if l.formatter == nil {
+		l.logger.Printf(format, v...)
+		return
+	}
+	l.Log(parseEntry(l.Level(), fmt.Sprintf(format, v...)))
+}
+
+// Log writes e using the Logger's Formatter. Without a Formatter, e is
+// written exactly as it would be by Printf.
+func (l *Logger) Log(e Entry) {
+	if l.formatter == nil {
+		format, args := e.printf()
+		l.logger.Printf(format, args...)
+		return
+	}
+	l.logger.Output(2, string(l.formatter.Format(e)))
 }
 
 // Level returns the log.Level for the Logger.
-func (l *Logger) Level() Level { return l.level }
+func (l *Logger) Level() Level { return Level(atomic.LoadInt32(&l.level)) }
+
+// SetLevel sets the Level for the Logger.
+func (l *Logger) SetLevel(level Level) { atomic.StoreInt32(&l.level, int32(level)) }
 
 // MultiLogger wraps multiple Loggable implementations.
 //
@@ -101,10 +330,24 @@ func (l *Logger) Level() Level { return l.level }
 // Loggable object in turn, where it will be logged if the Loggable's
 // level is less than or equal to the level of the message.
 //
+// A MultiLogger can carry fields, which are attached to every message
+// it logs. Child loggers carrying additional fields are created with
+// With and WithFields; a child shares its Loggables with the
+// MultiLogger it was created from.
+//
 // A MultiLogger can be used simultaneously from multiple goroutines.
 type MultiLogger struct {
 	loggables []Loggable
 	mu        *sync.RWMutex
+
+	parent       *MultiLogger // root MultiLogger, nil if this is the root.
+	name         string
+	fields       []Field
+	extractors   []Extractor
+	levels       []moduleLevel
+	sampler      *Sampler
+	redactor     *Redactor
+	reportCaller bool
 }
 
 // NewMultiLogger returns a ready to use MultiLogger
@@ -117,8 +360,62 @@ func NewMultiLogger(loggables ...Loggable) *MultiLogger {
 	return l
 }
 
+// root returns the MultiLogger holding the Loggables for m.
+func (m *MultiLogger) root() *MultiLogger {
+	if m.parent != nil {
+		return m.parent
+	}
+	return m
+}
+
+// With returns a child MultiLogger that attaches the provided fields to
+// every message it logs, in addition to any fields carried by m.
+//
+// Fields are provided as alternating keys and values, e.g.,
+//
+//	l := m.With("request_id", id, "user_id", uid)
+//
+// Field and Fields values may also be passed in place of a key.
+func (m *MultiLogger) With(keyvals ...interface{}) *MultiLogger {
+	return m.withFields(toFields(keyvals))
+}
+
+// With returns a child of the package-level MultiLogger carrying the
+// provided fields.
+func With(keyvals ...interface{}) *MultiLogger {
+	return std.With(keyvals...)
+}
+
+// WithFields returns a child MultiLogger that attaches fields to every
+// message it logs. Fields are attached in key order.
+func (m *MultiLogger) WithFields(fields map[string]interface{}) *MultiLogger {
+	return m.withFields(Fields(fields).sorted())
+}
+
+// WithFields returns a child of the package-level MultiLogger carrying
+// the provided fields.
+func WithFields(fields map[string]interface{}) *MultiLogger {
+	return std.WithFields(fields)
+}
+
+// withFields returns a child MultiLogger carrying m's fields followed
+// by fields.
+func (m *MultiLogger) withFields(fields []Field) *MultiLogger {
+	all := make([]Field, 0, len(m.fields)+len(fields))
+	all = append(append(all, m.fields...), fields...)
+	return &MultiLogger{parent: m.root(), name: m.name, fields: all}
+}
+
 // Add adds the provided loggables to the MultiLogger.
+//
+// Calling Add on a child MultiLogger adds the loggables to the
+// MultiLogger it was created from.
 func (m *MultiLogger) Add(loggables ...Loggable) {
+	if m.parent != nil {
+		m.parent.Add(loggables...)
+		return
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.loggables = append(m.loggables, loggables...)
@@ -131,6 +428,11 @@ func Add(loggables ...Loggable) {
 
 // Reset removes all the registered Loggables from the MultiLogger.
 func (m *MultiLogger) Reset() {
+	if m.parent != nil {
+		m.parent.Reset()
+		return
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.loggables = make([]Loggable, 0)
@@ -142,10 +444,62 @@ func Reset() {
 	std.Reset()
 }
 
-// CapturePanic logs panics with a level ERROR
+// SetLevel calls SetLevel on each of the MultiLogger's Loggables that
+// implement LevelSetter.
+func (m *MultiLogger) SetLevel(level Level) {
+	r := m.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, l := range r.loggables {
+		if ls, ok := l.(LevelSetter); ok {
+			ls.SetLevel(level)
+		}
+	}
+}
+
+// SetLevel sets the level of the Loggables registered on the
+// package-level MultiLogger.
+func SetLevel(level Level) {
+	std.SetLevel(level)
+}
+
+// Flush calls Flush on each of the MultiLogger's Loggables that
+// implement Flusher, returning the first error encountered.
+//
+// If a Sampler is set, Flush first logs summaries of any messages it
+// has suppressed.
+func (m *MultiLogger) Flush() error {
+	r := m.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.sampler != nil {
+		r.logSummaries(r.sampler.summarise())
+	}
+
+	var err error
+	for _, l := range r.loggables {
+		if f, ok := l.(Flusher); ok {
+			if ferr := f.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+// Flush flushes the Loggables registered on the package-level
+// MultiLogger.
+func Flush() error {
+	return std.Flush()
+}
+
+// CapturePanic logs panics with a level ERROR, attaching the
+// goroutine's stack trace as the StackKey field.
 func (m *MultiLogger) CapturePanic() {
 	if rec := recover(); rec != nil {
-		m.Error(rec)
+		m.With(StackKey, string(debug.Stack())).Error(rec)
 		panic(rec)
 	}
 }
@@ -155,9 +509,69 @@ func CapturePanic() {
 	std.CapturePanic()
 }
 
+// Fatalf prints to all loggers, flushes them, and then calls
+// os.Exit(1).
+func (m *MultiLogger) Fatalf(format string, v ...interface{}) {
+	m.prntf(nil, FATAL, format, v...)
+	m.Flush()
+	exit(1)
+}
+
+// Fatalf prints to all loggers registered within the iylog package
+// standard logger, flushes them, and then calls os.Exit(1).
+func Fatalf(format string, v ...interface{}) {
+	std.Fatalf(format, v...)
+}
+
+// Fatal prints to all loggers, flushes them, and then calls
+// os.Exit(1).
+func (m *MultiLogger) Fatal(v ...interface{}) {
+	m.print(nil, FATAL, v...)
+	m.Flush()
+	exit(1)
+}
+
+// Fatal prints to all loggers registered within the iylog package
+// standard logger, flushes them, and then calls os.Exit(1).
+func Fatal(v ...interface{}) {
+	std.Fatal(v...)
+}
+
+// Panicf prints to all loggers with a level of PANIC or above, flushes
+// them, and then panics with the formatted message.
+func (m *MultiLogger) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	m.prntf(nil, PANIC, format, v...)
+	m.Flush()
+	panic(msg)
+}
+
+// Panicf prints to all loggers registered within the iylog package
+// standard logger, with a level of PANIC or above, flushes them, and
+// then panics with the formatted message.
+func Panicf(format string, v ...interface{}) {
+	std.Panicf(format, v...)
+}
+
+// Panic prints to all loggers with a level of PANIC or above, flushes
+// them, and then panics with the message.
+func (m *MultiLogger) Panic(v ...interface{}) {
+	msg := fmt.Sprintf(printFormat(len(v)), v...)
+	m.print(nil, PANIC, v...)
+	m.Flush()
+	panic(msg)
+}
+
+// Panic prints to all loggers registered within the iylog package
+// standard logger, with a level of PANIC or above, flushes them, and
+// then panics with the message.
+func Panic(v ...interface{}) {
+	std.Panic(v...)
+}
+
 // Errorf prints to all loggers with a level of ERROR or above
 func (m *MultiLogger) Errorf(format string, v ...interface{}) {
-	m.prntf(ERROR, format, v...)
+	m.prntf(nil, ERROR, format, v...)
 }
 
 // Errorf prints to all loggers registered within the iylog
@@ -168,7 +582,7 @@ func Errorf(format string, v ...interface{}) {
 
 // Error prints to all loggers with a level of ERROR or above
 func (m *MultiLogger) Error(v ...interface{}) {
-	m.print(ERROR, v...)
+	m.print(nil, ERROR, v...)
 }
 
 // Error prints to all loggers registered within the iylog
@@ -179,7 +593,7 @@ func Error(v ...interface{}) {
 
 // Warningf prints to all loggers with a level of WARNING or above
 func (m *MultiLogger) Warningf(format string, v ...interface{}) {
-	m.prntf(WARNING, format, v...)
+	m.prntf(nil, WARNING, format, v...)
 }
 
 // Warningf prints to all loggers registered within the iylog
@@ -190,7 +604,7 @@ func Warningf(format string, v ...interface{}) {
 
 // Warning prints to all loggers with a level of WARNING or above
 func (m *MultiLogger) Warning(v ...interface{}) {
-	m.print(WARNING, v...)
+	m.print(nil, WARNING, v...)
 }
 
 // Warning prints to all loggers registered within the iylog
@@ -201,7 +615,7 @@ func Warning(v ...interface{}) {
 
 // Infof prints to all loggers with a level of INFO or above
 func (m *MultiLogger) Infof(format string, v ...interface{}) {
-	m.prntf(INFO, format, v...)
+	m.prntf(nil, INFO, format, v...)
 }
 
 // Infof prints to all loggers registered within the iylog
@@ -212,7 +626,7 @@ func Infof(format string, v ...interface{}) {
 
 // Info prints to all loggers with a level of INFO or above
 func (m *MultiLogger) Info(v ...interface{}) {
-	m.print(INFO, v...)
+	m.print(nil, INFO, v...)
 }
 
 // Info prints to all loggers registered within the iylog
@@ -223,7 +637,7 @@ func Info(v ...interface{}) {
 
 // Debugf prints to all loggers with a level of DEBUG
 func (m *MultiLogger) Debugf(format string, v ...interface{}) {
-	m.prntf(DEBUG, format, v...)
+	m.prntf(nil, DEBUG, format, v...)
 }
 
 // Debugf prints to all loggers registered within the iylog
@@ -234,7 +648,7 @@ func Debugf(format string, v ...interface{}) {
 
 // Debug prints to all loggers with a level of DEBUG or above
 func (m *MultiLogger) Debug(v ...interface{}) {
-	m.print(DEBUG, v...)
+	m.print(nil, DEBUG, v...)
 }
 
 // Debug prints to all loggers registered within the iylog
@@ -243,31 +657,142 @@ func Debug(v ...interface{}) {
 	std.Debug(v...)
 }
 
+// Tracef prints to all loggers with a level of TRACE
+func (m *MultiLogger) Tracef(format string, v ...interface{}) {
+	m.prntf(nil, TRACE, format, v...)
+}
+
+// Tracef prints to all loggers registered within the iylog
+// package standard logger, with a level of TRACE.
+func Tracef(format string, v ...interface{}) {
+	std.Tracef(format, v...)
+}
+
+// Trace prints to all loggers with a level of TRACE
+func (m *MultiLogger) Trace(v ...interface{}) {
+	m.print(nil, TRACE, v...)
+}
+
+// Trace prints to all loggers registered within the iylog
+// package standard logger, with a level of TRACE.
+func Trace(v ...interface{}) {
+	std.Trace(v...)
+}
+
 // prntf performs the printing and formatting of levels and messages
 // to the Loggers set of loggers.
-func (m *MultiLogger) prntf(level Level, format string, v ...interface{}) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	m.print(level, fmt.Sprintf(format, v...))
+//
+// Errors in v are described by fields when logging at ERROR or above.
+func (m *MultiLogger) prntf(ctx context.Context, level Level, format string, v ...interface{}) {
+	if level >= ERROR {
+		if fields := errorFields(v); len(fields) > 0 {
+			m = m.withFields(fields)
+		}
+	}
+	m.output(ctx, level, format, "%v", fmt.Sprintf(format, v...))
 }
 
 // print performs the printing of levels and messages
 // to the Logger's set of loggers.
-func (m *MultiLogger) print(level Level, v ...interface{}) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	format := fmt.Sprintf("[%s] ", level)
-	for i := 0; i < len(v); i++ {
-		format += "%v"
-		if i < len(v)-1 {
-			format += " "
+//
+// Errors in v are described by fields when logging at ERROR or above.
+func (m *MultiLogger) print(ctx context.Context, level Level, v ...interface{}) {
+	if level >= ERROR {
+		if fields := errorFields(v); len(fields) > 0 {
+			m = m.withFields(fields)
 		}
 	}
+	m.output(ctx, level, "", printFormat(len(v)), v...)
+}
+
+// printFormat returns a format for printing n space-separated values.
+func printFormat(n int) string {
+	return strings.TrimSuffix(strings.Repeat("%v ", n), " ")
+}
+
+// output passes an Entry to each Loggable listening at level, calling
+// Log on FieldLoggables and Printf on everything else.
+//
+// If ctx is not nil, any fields carried by ctx or returned by the
+// registered Extractors are attached to the Entry.
+//
+// If a Sampler is set, key identifies similar messages. An empty key
+// identifies messages by their formatted text.
+func (m *MultiLogger) output(ctx context.Context, level Level, key, format string, v ...interface{}) {
+	r := m.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.enabled(level) || level < r.moduleLevel(m.name) {
+		return
+	}
+
+	msg := fmt.Sprintf(format, v...)
+	if r.sampler != nil {
+		if key == "" {
+			key = msg
+		}
+
+		ok, summaries := r.sampler.sample(sampleKey{level: level, name: m.name, format: key})
+		r.logSummaries(summaries)
+		if !ok {
+			return
+		}
+	}
+
+	fields := m.fields
+	if ctx != nil {
+		fields = r.contextFields(ctx, fields)
+	}
+	var cf []Field
+	if r.reportCaller || r.reportsCaller(level) {
+		cf = callerFields()
+	}
+	if r.reportCaller {
+		fields = append(fields[:len(fields):len(fields)], cf...)
+	}
+
+	e := Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Name:    m.name,
+		Message: msg,
+		Fields:  fields,
+		format:  format,
+		args:    v,
+	}
+	e.Caller, _, _ = fieldValue(cf, CallerKey)
+	if r.redactor != nil {
+		e = r.redactor.redactEntry(e)
+	}
+
+	r.log(e)
+}
+
+// log passes e to each Loggable listening at e's level. The caller
+// must hold the read lock.
+func (m *MultiLogger) log(e Entry) {
+	for _, l := range m.loggables {
+		if e.Level < l.Level() {
+			continue
+		}
+
+		if fl, ok := l.(FieldLoggable); ok {
+			fl.Log(e)
+			continue
+		}
+		format, args := e.printf()
+		l.Printf(format, args...)
+	}
+}
 
+// enabled determines if any of the MultiLogger's Loggables are
+// listening at level. The caller must hold the read lock.
+func (m *MultiLogger) enabled(level Level) bool {
 	for _, l := range m.loggables {
 		if level >= l.Level() {
-			l.Printf(format, v...)
+			return true
 		}
 	}
+	return false
 }
@@ -2,6 +2,7 @@ package iylog
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -23,14 +24,127 @@ func TestLevel_ToLevel(t *testing.T) {
 		{In: "WARNING", Out: WARNING},
 		{In: "error", Out: ERROR},
 		{In: "ERROR", Out: ERROR},
+		{In: "trace", Out: TRACE},
+		{In: "Panic", Out: PANIC},
+		{In: "FATAL", Out: FATAL},
 	}
 
 	for _, ex := range examples {
-		l := ToLevel(ex.In)
+		l, err := ToLevel(ex.In)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
 		if l != ex.Out {
 			t.Errorf(expFmt, ex.Out, l)
 		}
 	}
+
+	// Unknown levels return an error.
+	if l, err := ToLevel("LOUD"); err == nil || l != DEBUG {
+		t.Errorf(expFmt, "DEBUG and an error", l)
+	}
+}
+
+func TestLevel_Text(t *testing.T) {
+	var conf struct {
+		Level Level `json:"level"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"level": "warning"}`), &conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Level != WARNING {
+		t.Errorf(expFmt, WARNING, conf.Level)
+	}
+
+	b, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := `{"level":"WARNING"}`; string(b) != exp {
+		t.Errorf(expFmt, exp, string(b))
+	}
+
+	if err := json.Unmarshal([]byte(`{"level": "LOUD"}`), &conf); err == nil {
+		t.Error("expected error for unknown level")
+	}
+
+	conf.Level = Level(3)
+	if _, err := json.Marshal(conf); err == nil {
+		t.Error("expected error for unknown level")
+	}
+
+	// The zero Level is TRACE, so an unset level can be encoded.
+	var zero struct {
+		Level Level `json:"level"`
+	}
+	if b, err := json.Marshal(zero); err != nil || string(b) != `{"level":"TRACE"}` {
+		t.Errorf(expFmt, `{"level":"TRACE"}`, string(b))
+	}
+}
+
+func TestLevel_Values(t *testing.T) {
+	// The values of the Levels are stored and transmitted, so mustn't
+	// change.
+	examples := []struct {
+		level Level
+		value int
+	}{
+		{TRACE, 0},
+		{DEBUG, 1},
+		{INFO, 2},
+		{WARNING, 4},
+		{ERROR, 8},
+		{PANIC, 16},
+		{FATAL, 32},
+	}
+
+	for i, ex := range examples {
+		if int(ex.level) != ex.value {
+			t.Errorf("[Example %d] "+expFmt, i+1, ex.value, int(ex.level))
+		}
+	}
+}
+
+func Test_Fatal(t *testing.T) {
+	defer func(f func(int)) { exit = f }(exit)
+	var code int
+	exit = func(c int) { code = c }
+
+	fl := &testFieldLogger{lvl: ERROR}
+	a := NewAsyncLogger(fl, 10, Block)
+	l := NewMultiLogger(a)
+
+	l.Fatalf("bad %s", "thing")
+
+	// The async logger was flushed before exiting.
+	if code != 1 {
+		t.Errorf(expFmt, 1, code)
+	}
+	if len(fl.entries) != 1 || fl.entries[0].String() != "[FATAL] bad thing" {
+		t.Errorf(expFmt, "[FATAL] bad thing", fl.entries)
+	}
+}
+
+func Test_Panic(t *testing.T) {
+	tl := &testLogger{buf: &bytes.Buffer{}, lvl: TRACE}
+	l := NewMultiLogger(tl)
+
+	l.Trace("t")
+	if obt := tl.buf.String(); obt != "[TRACE] t" {
+		t.Errorf(expFmt, "[TRACE] t", obt)
+	}
+	tl.buf.Reset()
+
+	defer func() {
+		if rec := recover(); rec != "a 1" {
+			t.Errorf(expFmt, "a 1", rec)
+		}
+		if obt := tl.buf.String(); obt != "[PANIC] a 1" {
+			t.Errorf(expFmt, "[PANIC] a 1", obt)
+		}
+	}()
+	l.Panic("a", 1)
 }
 
 func Test_NewMultiLogger(t *testing.T) {
@@ -309,3 +423,98 @@ func (t *testLogger) Printf(f string, v ...interface{}) {
 func (t *testLogger) Level() Level {
 	return t.lvl
 }
+
+func Test_MultiLoggerWith(t *testing.T) {
+	tl := &testLogger{buf: &bytes.Buffer{}, lvl: DEBUG}
+	l := NewMultiLogger(tl)
+
+	child := l.With("request_id", "a3f9", "n", 2)
+	child.Infof("handled %s", "request")
+
+	exp := "[INFO] handled request request_id=a3f9 n=2"
+	if obt := tl.buf.String(); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+	tl.buf.Reset()
+
+	// Fields accumulate on children of children, and maps are sorted.
+	child.WithFields(map[string]interface{}{"b": 1, "a": 0}).Error("x", "y")
+	exp = "[ERROR] x y request_id=a3f9 n=2 a=0 b=1"
+	if obt := tl.buf.String(); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+	tl.buf.Reset()
+
+	// The parent is unaffected by its children's fields.
+	l.Info("plain")
+	exp = "[INFO] plain"
+	if obt := tl.buf.String(); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+	tl.buf.Reset()
+
+	// Loggables added to a child are shared with the parent.
+	tl2 := &testLogger{buf: &bytes.Buffer{}, lvl: DEBUG}
+	child.Add(tl2)
+	l.Info("shared")
+	if obt := tl2.buf.String(); obt != "[INFO] shared" {
+		t.Errorf(expFmt, "[INFO] shared", obt)
+	}
+}
+
+func Test_MultiLoggerWith_MissingValue(t *testing.T) {
+	tl := &testLogger{buf: &bytes.Buffer{}, lvl: DEBUG}
+	NewMultiLogger(tl).With(Field{Key: "a", Value: 1}, "b").Debug("m")
+
+	exp := "[DEBUG] m a=1 b=(MISSING)"
+	if obt := tl.buf.String(); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+}
+
+func Test_FieldLoggable(t *testing.T) {
+	fl := &testFieldLogger{lvl: INFO}
+	l := NewMultiLogger(fl).With("k", "v")
+
+	l.Debug("ignored")
+	l.Warningf("%d things", 3)
+
+	if len(fl.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(fl.entries))
+	}
+
+	e := fl.entries[0]
+	if e.Level != WARNING {
+		t.Errorf(expFmt, WARNING, e.Level)
+	}
+	if e.Message != "3 things" {
+		t.Errorf(expFmt, "3 things", e.Message)
+	}
+	if exp := []Field{{Key: "k", Value: "v"}}; !reflect.DeepEqual(e.Fields, exp) {
+		t.Errorf(expFmt, exp, e.Fields)
+	}
+	if e.Time.IsZero() {
+		t.Error("expected entry time to be set")
+	}
+	if exp := "[WARNING] 3 things k=v"; e.String() != exp {
+		t.Errorf(expFmt, exp, e.String())
+	}
+}
+
+// testFieldLogger implements iylog.FieldLoggable, recording each
+// Entry passed to Log.
+type testFieldLogger struct {
+	testLogger
+	lvl     Level
+	entries []Entry
+}
+
+// Log records e.
+func (t *testFieldLogger) Log(e Entry) {
+	t.entries = append(t.entries, e)
+}
+
+// Level returns the testFieldLogger.lvl
+func (t *testFieldLogger) Level() Level {
+	return t.lvl
+}
@@ -1,8 +1,11 @@
 package iylog
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
 	"sync"
 )
 
@@ -12,12 +15,19 @@ import (
 // a MemLogger will capture any logged messages at level DEBUG or
 // above. This can be changed using the SetLevel method.
 //
+// Each message is captured both as the format and arguments a Loggable
+// would receive in a call to Printf, and as an Entry recording its
+// level, message, fields and time separately. The matching methods,
+// such as Contains and CountAt, never panic, and the Assert methods
+// fail a test with a dump of every captured Entry.
+//
 // A MemLogger is safe for use by multiple goroutines.
 type MemLogger struct {
 	i int
 
 	mu       sync.Mutex
 	messages []Message
+	entries  []Entry
 	level    Level
 }
 
@@ -37,13 +47,28 @@ func NewMemLogger() *MemLogger {
 func (l *MemLogger) Reset() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.messages, l.i = []Message{}, 0
+	l.messages, l.entries, l.i = []Message{}, []Entry{}, 0
 }
 
+// Printf captures the formatted message. If the message starts with a
+// level, such as "[INFO] ", the captured Entry is given that level, and
+// otherwise the MemLogger's level.
 func (l *MemLogger) Printf(format string, v ...interface{}) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.messages = append(l.messages, Message{Format: format, Args: v})
+
+	l.entries = append(l.entries, parseEntry(l.level, fmt.Sprintf(format, v...)))
+}
+
+// Log captures e.
+func (l *MemLogger) Log(e Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	format, args := e.printf()
+	l.messages = append(l.messages, Message{Format: format, Args: args})
+	l.entries = append(l.entries, e)
 }
 
 func (l *MemLogger) Level() Level {
@@ -110,3 +135,114 @@ func (l *MemLogger) LastCalledWith(format string, v ...interface{}) bool {
 	}
 	return format == l.messages[len(l.messages)-1].Format && reflect.DeepEqual(v, l.messages[len(l.messages)-1].Args)
 }
+
+// Entries returns a copy of the captured entries.
+func (l *MemLogger) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// find returns the captured entries for which match returns true.
+func (l *MemLogger) find(match func(Entry) bool) []Entry {
+	var found []Entry
+	for _, e := range l.Entries() {
+		if match(e) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Contains determines if any captured entry, rendered as a single line
+// by Entry.String, contains substr.
+func (l *MemLogger) Contains(substr string) bool {
+	return len(l.find(func(e Entry) bool { return strings.Contains(e.String(), substr) })) > 0
+}
+
+// Matches determines if any captured entry, rendered as a single line
+// by Entry.String, matches re.
+func (l *MemLogger) Matches(re *regexp.Regexp) bool {
+	return len(l.find(func(e Entry) bool { return re.MatchString(e.String()) })) > 0
+}
+
+// AtLevel returns the captured entries logged at level.
+func (l *MemLogger) AtLevel(level Level) []Entry {
+	return l.find(func(e Entry) bool { return e.Level == level })
+}
+
+// CountAt returns the number of captured entries logged at level.
+func (l *MemLogger) CountAt(level Level) int {
+	return len(l.AtLevel(level))
+}
+
+// NoErrors determines if no entries were captured at ERROR or above.
+func (l *MemLogger) NoErrors() bool {
+	return len(l.find(func(e Entry) bool { return e.Level >= ERROR })) == 0
+}
+
+// Dump returns every captured entry, one per line, prefixed with the
+// time it was logged.
+func (l *MemLogger) Dump() string {
+	entries := l.Entries()
+	if len(entries) == 0 {
+		return "no entries captured"
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d entries captured:\n", len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\t%s %s\n", e.Time.Format("15:04:05.000000"), e)
+	}
+	return b.String()
+}
+
+// TestingT is the subset of testing.TB used by the Assert methods of a
+// MemLogger, so that non-test code needn't import the testing package.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertContains fails the test if no captured entry contains substr.
+func (l *MemLogger) AssertContains(t TestingT, substr string) {
+	t.Helper()
+	if !l.Contains(substr) {
+		t.Errorf("expected an entry containing %q\n%s", substr, l.Dump())
+	}
+}
+
+// AssertNotContains fails the test if any captured entry contains
+// substr.
+func (l *MemLogger) AssertNotContains(t TestingT, substr string) {
+	t.Helper()
+	if l.Contains(substr) {
+		t.Errorf("expected no entry containing %q\n%s", substr, l.Dump())
+	}
+}
+
+// AssertMatches fails the test if no captured entry matches re.
+func (l *MemLogger) AssertMatches(t TestingT, re *regexp.Regexp) {
+	t.Helper()
+	if !l.Matches(re) {
+		t.Errorf("expected an entry matching %q\n%s", re, l.Dump())
+	}
+}
+
+// AssertCountAt fails the test if the number of entries captured at
+// level is not n.
+func (l *MemLogger) AssertCountAt(t TestingT, level Level, n int) {
+	t.Helper()
+	if c := l.CountAt(level); c != n {
+		t.Errorf("expected %d entries at %v, got %d\n%s", n, level, c, l.Dump())
+	}
+}
+
+// AssertNoErrors fails the test if any entries were captured at ERROR
+// or above.
+func (l *MemLogger) AssertNoErrors(t TestingT) {
+	t.Helper()
+	if !l.NoErrors() {
+		t.Errorf("expected no entries at ERROR or above\n%s", l.Dump())
+	}
+}
@@ -0,0 +1,88 @@
+package iylog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMemLogger(t *testing.T) {
+	ml := NewMemLogger()
+	l := NewMultiLogger(ml)
+
+	if !ml.NoErrors() || ml.Contains("x") || ml.CountAt(INFO) != 0 {
+		t.Error("expected no matches before logging")
+	}
+
+	l.With("user_id", 42).Infof("hello %s", "world")
+	l.Warning("careful", 1)
+	ml.Printf("[ERROR] %s", "direct")
+
+	// The legacy format and arguments are still captured.
+	if !ml.CalledWith("[INFO] %v %s=%v", "hello world", "user_id", 42) {
+		t.Errorf("expected CalledWith to match, got %v", ml.Messages())
+	}
+	if !ml.CalledWith("[WARNING] %v %v", "careful", 1) {
+		t.Errorf("expected CalledWith to match, got %v", ml.Messages())
+	}
+
+	entries := ml.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Level != INFO || e.Message != "hello world" || len(e.Fields) != 1 || e.Time.IsZero() {
+		t.Errorf("unexpected entry %#v", e)
+	}
+	if e := entries[2]; e.Level != ERROR || e.Message != "direct" {
+		t.Errorf("unexpected entry %#v", e)
+	}
+
+	if !ml.Contains("user_id=42") || ml.Contains("goodbye") {
+		t.Error("unexpected Contains result")
+	}
+	if !ml.Matches(regexp.MustCompile(`^\[WARNING\] careful \d$`)) {
+		t.Error("expected Matches to match")
+	}
+	if n := ml.CountAt(WARNING); n != 1 {
+		t.Errorf(expFmt, 1, n)
+	}
+	if ml.NoErrors() {
+		t.Error("expected errors")
+	}
+
+	ml.AssertContains(t, "hello")
+	ml.AssertCountAt(t, INFO, 1)
+}
+
+func TestMemLogger_Assertions(t *testing.T) {
+	ml := NewMemLogger()
+	NewMultiLogger(ml).Error("boom")
+
+	rt := &recordingT{}
+	ml.AssertNoErrors(rt)
+	ml.AssertContains(rt, "missing")
+	ml.AssertNotContains(rt, "boom")
+	ml.AssertMatches(rt, regexp.MustCompile("^nope$"))
+	ml.AssertCountAt(rt, ERROR, 2)
+
+	if len(rt.errors) != 5 {
+		t.Fatalf("expected 5 failures, got %d", len(rt.errors))
+	}
+	for _, msg := range rt.errors {
+		if !strings.Contains(msg, "1 entries captured:") || !strings.Contains(msg, "[ERROR] boom") {
+			t.Errorf("expected dump in failure, got %q", msg)
+		}
+	}
+}
+
+// recordingT records failures instead of failing the test.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, v ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, v...))
+}
@@ -0,0 +1,71 @@
+package iylog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMultiLogger_Named(t *testing.T) {
+	tl := &testLogger{buf: &bytes.Buffer{}, lvl: DEBUG}
+	l := NewMultiLogger(tl)
+
+	n := l.With("k", "v").Named("billing").Named("invoices")
+	if n.Name() != "billing.invoices" {
+		t.Errorf(expFmt, "billing.invoices", n.Name())
+	}
+
+	n.Infof("%d%%", 100)
+	exp := "[INFO] billing.invoices: 100% k=v"
+	if obt := tl.buf.String(); obt != exp {
+		t.Errorf(expFmt, exp, obt)
+	}
+}
+
+func TestMultiLogger_SetLevelSpec(t *testing.T) {
+	tl := &testLogger{buf: &bytes.Buffer{}, lvl: DEBUG}
+	l := NewMultiLogger(tl)
+
+	if err := l.SetLevelSpec("billing=DEBUG, billing.stripe=INFO,*=WARNING"); err != nil {
+		t.Fatal(err)
+	}
+
+	examples := []struct {
+		l      *MultiLogger
+		level  Level
+		logged bool
+	}{
+		{l: l.Named("billing"), level: DEBUG, logged: true},
+		{l: l.Named("billing.invoices"), level: DEBUG, logged: true},
+		{l: l.Named("billing.stripe.webhooks"), level: DEBUG, logged: false},
+		{l: l.Named("billing.stripe.webhooks"), level: INFO, logged: true},
+		{l: l.Named("billingx"), level: INFO, logged: false},
+		{l: l.Named("auth"), level: WARNING, logged: true},
+		{l: l, level: INFO, logged: false},
+		{l: l, level: ERROR, logged: true},
+	}
+
+	for i, ex := range examples {
+		tl.buf.Reset()
+		ex.l.output(nil, ex.level, "", "%v", "x")
+		if obt := tl.buf.Len() > 0; obt != ex.logged {
+			t.Errorf("[Example %d] "+expFmt, i, ex.logged, obt)
+		}
+	}
+
+	// Removing the spec logs everything again.
+	l.SetLevelSpec("")
+	tl.buf.Reset()
+	l.Named("auth").Debug("x")
+	if tl.buf.Len() == 0 {
+		t.Error("expected message to be logged")
+	}
+}
+
+func TestMultiLogger_SetLevelSpec_Invalid(t *testing.T) {
+	l := NewMultiLogger()
+	for _, spec := range []string{"billing", "billing=LOUD", "=DEBUG"} {
+		if err := l.SetLevelSpec(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package iylog
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// redacted replaces redacted values.
+const redacted = "[REDACTED]"
+
+// DefaultRedactKeys are the field names redacted by DefaultRedactor.
+var DefaultRedactKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"api_key",
+	"apikey",
+	"authorization",
+	"cookie",
+	"sh_key",
+}
+
+// Patterns matching sensitive values, used by RedactCreditCards,
+// RedactBearerTokens and RedactEmails.
+var (
+	CreditCardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	BearerTokenPattern = regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	EmailPattern       = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// Secret is a string that is never logged. A Secret always renders as
+// "[REDACTED]", whether it's formatted by the fmt package or marshaled
+// as JSON, so it can be passed to logging functions, or stored in
+// structs that are, without revealing its value.
+//
+// The value of a Secret is retrieved by converting it to a string.
+type Secret string
+
+// String implements the fmt.Stringer interface.
+func (s Secret) String() string { return redacted }
+
+// Format implements the fmt.Formatter interface, so that the Secret is
+// redacted whatever verb it's formatted with.
+func (s Secret) Format(f fmt.State, verb rune) { io.WriteString(f, redacted) }
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s Secret) MarshalJSON() ([]byte, error) { return []byte(`"` + redacted + `"`), nil }
+
+// redactRule replaces sensitive values matched by a pattern.
+type redactRule struct {
+	re      *regexp.Regexp
+	replace func(match []string) string
+}
+
+// A Redactor masks sensitive values in messages before they reach any
+// Loggables. Fields with sensitive names have their values replaced
+// entirely, and values matching sensitive patterns are replaced within
+// messages and field values.
+//
+// A Redactor is safe for use by multiple goroutines.
+type Redactor struct {
+	keys  map[string]bool
+	rules []redactRule
+}
+
+// RedactOption is a functional option for the Redactor type.
+type RedactOption func(*Redactor)
+
+// RedactKeys redacts the values of fields with the provided names.
+//
+// Names are split into words, separated by punctuation, spaces, or a
+// change from lower to upper case, and match field names ending in the
+// same words, whatever their case or separators. E.g., "password"
+// matches "user.password" and "db_password", and "api_key" matches
+// "x-api-key" and "apiKey", but "token" doesn't match "token_count".
+func RedactKeys(keys ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, k := range keys {
+			r.keys[strings.Join(keyWords(k), "_")] = true
+		}
+	}
+}
+
+// RedactPatterns redacts all matches of the provided patterns.
+func RedactPatterns(patterns ...*regexp.Regexp) RedactOption {
+	return func(r *Redactor) {
+		for _, re := range patterns {
+			r.rules = append(r.rules, redactRule{re: re})
+		}
+	}
+}
+
+// RedactCreditCards redacts credit card numbers, matched by
+// CreditCardPattern and then checked using the Luhn algorithm, so that
+// other long numbers, such as timestamps, are mostly left alone.
+func RedactCreditCards() RedactOption {
+	return func(r *Redactor) {
+		r.rules = append(r.rules, redactRule{
+			re: CreditCardPattern,
+			replace: func(m []string) string {
+				if luhn(m[0]) {
+					return redacted
+				}
+				return m[0]
+			},
+		})
+	}
+}
+
+// RedactBearerTokens redacts bearer tokens, such as those in
+// Authorization headers, e.g., "Bearer abc123" becomes
+// "Bearer [REDACTED]".
+func RedactBearerTokens() RedactOption {
+	return func(r *Redactor) {
+		r.rules = append(r.rules, redactRule{
+			re:      BearerTokenPattern,
+			replace: func(m []string) string { return m[1] + redacted },
+		})
+	}
+}
+
+// RedactEmails redacts email addresses.
+func RedactEmails() RedactOption {
+	return RedactPatterns(EmailPattern)
+}
+
+// NewRedactor returns a new Redactor. With no options, the Redactor
+// redacts nothing.
+func NewRedactor(options ...RedactOption) *Redactor {
+	r := &Redactor{keys: map[string]bool{}}
+
+	// Apply any options.
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// DefaultRedactor returns a Redactor which redacts the fields named in
+// DefaultRedactKeys, along with credit card numbers, bearer tokens and
+// email addresses.
+func DefaultRedactor() *Redactor {
+	return NewRedactor(
+		RedactKeys(DefaultRedactKeys...),
+		RedactCreditCards(),
+		RedactBearerTokens(),
+		RedactEmails(),
+	)
+}
+
+// Redact returns s with all values matching the Redactor's patterns
+// replaced.
+func (r *Redactor) Redact(s string) string {
+	for _, rule := range r.rules {
+		if rule.replace == nil {
+			s = rule.re.ReplaceAllLiteralString(s, redacted)
+			continue
+		}
+
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			return rule.replace(rule.re.FindStringSubmatch(match))
+		})
+	}
+	return s
+}
+
+// redactKey determines if the value of the field named k should be
+// redacted, because it ends in the words of one of the Redactor's keys.
+func (r *Redactor) redactKey(k string) bool {
+	words := keyWords(k)
+	for i := range words {
+		if r.keys[strings.Join(words[i:], "_")] {
+			return true
+		}
+	}
+	return false
+}
+
+// keyWords splits the field name k into lower case words, separated by
+// punctuation, spaces, or a change from lower to upper case, e.g.,
+// "X-Api-Key" and "xApiKey" both become "x", "api" and "key".
+func keyWords(k string) []string {
+	var words []string
+	var word []rune
+	split := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+
+	lower := false
+	for _, c := range k {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			split()
+			lower = false
+			continue
+		}
+		if unicode.IsUpper(c) && lower {
+			split()
+		}
+		word = append(word, unicode.ToLower(c))
+		lower = !unicode.IsUpper(c)
+	}
+	split()
+	return words
+}
+
+// redactEntry returns e with its message and fields redacted.
+func (r *Redactor) redactEntry(e Entry) Entry {
+	e.Message = r.Redact(e.Message)
+
+	// Drop the message's arguments, so that Loggables receiving the
+	// Entry rendered as a single line receive the redacted message.
+	e.format, e.args = "", nil
+
+	if len(e.Fields) == 0 {
+		return e
+	}
+
+	fields := make([]Field, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = Field{Key: f.Key, Value: r.redactValue(f.Key, f.Value)}
+	}
+	e.Fields = fields
+	return e
+}
+
+// redactValue returns the value of the field named k, redacted if
+// necessary. Numbers and booleans are only redacted because of their
+// field's name. Other values are redacted if their formatted value
+// matches one of the Redactor's patterns, in which case the redacted
+// formatted value is returned.
+func (r *Redactor) redactValue(k string, v interface{}) interface{} {
+	if r.redactKey(k) {
+		return Secret("")
+	}
+
+	switch v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16,
+		uint32, uint64, float32, float64, Secret:
+		return v
+	}
+
+	s := fmt.Sprint(v)
+	if rs := r.Redact(s); rs != s {
+		return rs
+	}
+	return v
+}
+
+// luhn determines if the digits in s pass the Luhn checksum.
+func luhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
+
+// SetRedactor sets the Redactor used to mask sensitive values before
+// they reach any of the MultiLogger's Loggables. A nil Redactor
+// disables redaction.
+//
+// Calling SetRedactor on a child MultiLogger sets the Redactor on the
+// MultiLogger it was created from.
+func (m *MultiLogger) SetRedactor(r *Redactor) {
+	root := m.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.redactor = r
+}
+
+// SetRedactor sets the Redactor on the package-level MultiLogger.
+func SetRedactor(r *Redactor) {
+	std.SetRedactor(r)
+}
@@ -0,0 +1,124 @@
+package iylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	s := Secret("hunter2")
+
+	for _, format := range []string{"%v", "%s", "%q", "%#v", "%+v", "%x"} {
+		if obt := fmt.Sprintf(format, s); obt != "[REDACTED]" {
+			t.Errorf("%s: "+expFmt, format, "[REDACTED]", obt)
+		}
+	}
+
+	b, err := json.Marshal(struct{ Key Secret }{Key: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := `{"Key":"[REDACTED]"}`; string(b) != exp {
+		t.Errorf(expFmt, exp, string(b))
+	}
+
+	if string(s) != "hunter2" {
+		t.Errorf(expFmt, "hunter2", string(s))
+	}
+}
+
+func TestRedactor_Redact(t *testing.T) {
+	r := DefaultRedactor()
+
+	examples := []struct {
+		In, Out string
+	}{
+		{In: "card 4111 1111 1111 1111 declined", Out: "card [REDACTED] declined"},
+		{In: "card 4111-1111-1111-1112 invalid", Out: "card 4111-1111-1111-1112 invalid"},
+		{In: "at 1451703845000", Out: "at 1451703845000"},
+		{In: "Authorization: Bearer abc.DEF-123=", Out: "Authorization: Bearer [REDACTED]"},
+		{In: "user jo.bloggs+x@example.co.uk signed up", Out: "user [REDACTED] signed up"},
+	}
+
+	for i, ex := range examples {
+		if obt := r.Redact(ex.In); obt != ex.Out {
+			t.Errorf("[Example %d] "+expFmt, i, ex.Out, obt)
+		}
+	}
+}
+
+func TestMultiLogger_SetRedactor(t *testing.T) {
+	fl := &testFieldLogger{lvl: DEBUG}
+	tl := &testLogger{buf: &bytes.Buffer{}, lvl: DEBUG}
+	l := NewMultiLogger(fl, tl)
+	l.SetRedactor(NewRedactor(
+		RedactKeys("SH_KEY", "password"),
+		RedactEmails(),
+		RedactPatterns(regexp.MustCompile(`sk_live_\w+`)),
+	))
+
+	l.With(
+		"sh_key", "abc",
+		"user.Password", 123,
+		"email", []string{"a@example.com"},
+		"n", 4,
+	).Infof("posting for %s with %v", "a@example.com", "sk_live_123")
+
+	exp := []Field{
+		{Key: "sh_key", Value: Secret("")},
+		{Key: "user.Password", Value: Secret("")},
+		{Key: "email", Value: "[[REDACTED]]"},
+		{Key: "n", Value: 4},
+	}
+	if obt := fl.entries[0].Fields; !reflect.DeepEqual(obt, exp) {
+		t.Errorf(expFmt, exp, obt)
+	}
+
+	expMsg := "posting for [REDACTED] with [REDACTED]"
+	if obt := fl.entries[0].Message; obt != expMsg {
+		t.Errorf(expFmt, expMsg, obt)
+	}
+
+	// Loggables receiving rendered lines are also redacted.
+	expLine := "[INFO] " + expMsg + " sh_key=[REDACTED] user.Password=[REDACTED] email=[[REDACTED]] n=4"
+	if obt := tl.buf.String(); obt != expLine {
+		t.Errorf(expFmt, expLine, obt)
+	}
+}
+
+func TestRedactor_RedactKey(t *testing.T) {
+	r := DefaultRedactor()
+	examples := []struct {
+		key string
+		exp bool
+	}{
+		{"password", true},
+		{"user.Password", true},
+		{"db_password", true},
+		{"DB_PASSWORD", true},
+		{"access_token", true},
+		{"refresh_token", true},
+		{"accessToken", true},
+		{"x-api-key", true},
+		{"X-Api-Key", true},
+		{"apiKey", true},
+		{"APIKEY", true},
+		{"client-secret", true},
+		{"Set-Cookie", true},
+		{"sh_key", true},
+		{"token_count", false},
+		{"key", false},
+		{"monkey", false},
+		{"user_id", false},
+	}
+
+	for i, ex := range examples {
+		if obt := r.redactKey(ex.key); obt != ex.exp {
+			t.Errorf("[Example %d] %s: "+expFmt, i+1, ex.key, ex.exp, obt)
+		}
+	}
+}
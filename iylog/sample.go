@@ -0,0 +1,254 @@
+package iylog
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sampleKey identifies similar messages.
+type sampleKey struct {
+	level  Level
+	name   string
+	format string
+}
+
+// sampleCount counts similar messages within an interval.
+type sampleCount struct {
+	n          int
+	suppressed int
+}
+
+// summary describes messages suppressed by a Sampler.
+type summary struct {
+	key        sampleKey
+	suppressed int
+}
+
+// A Sampler limits how often similar messages are logged by a
+// MultiLogger, for use on hot paths where the same message may be
+// logged thousands of times a second.
+//
+// Messages are similar if they're logged at the same level, by loggers
+// with the same name, and with the same format string. For the
+// functions that don't take a format string, such as Info, messages are
+// similar if their text is identical.
+//
+// Within each interval, the first First similar messages are logged,
+// and after that every Thereafter'th message. If Thereafter is zero,
+// no more similar messages are logged until the next interval. If the
+// interval is zero or negative, it never ends, so only the first First
+// similar messages are ever logged, and every Thereafter'th message
+// after that.
+//
+// Once an interval has elapsed, a summary of the messages suppressed
+// during it is logged, e.g.,
+//
+//	[WARNING] suppressed 4321 similar messages: dropped count for %v suppressed=4321
+//
+// Summaries are logged by the MultiLogger the Sampler is set on, which
+// checks whether the interval has elapsed once per interval, and
+// before logging each message, so a summary is logged at most an
+// interval after the end of the interval it summarises. A summary of
+// the current interval is also logged when the MultiLogger is flushed.
+// Stop stops the periodic summaries.
+//
+// A Sampler is safe for use by multiple goroutines.
+type Sampler struct {
+	first      int
+	thereafter int
+	interval   time.Duration
+	now        func() time.Time
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[sampleKey]*sampleCount
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSampler returns a new Sampler which logs the first similar
+// messages in each interval, and every thereafter'th message after
+// that. An interval of zero or less never ends.
+func NewSampler(first, thereafter int, interval time.Duration) *Sampler {
+	return &Sampler{
+		first:      first,
+		thereafter: thereafter,
+		interval:   interval,
+		now:        time.Now,
+		counts:     map[sampleKey]*sampleCount{},
+		stop:       make(chan struct{}),
+	}
+}
+
+// Stop stops the MultiLoggers the Sampler is set on from logging
+// summaries once per interval. Summaries are still logged before the
+// next message, and when the MultiLogger is flushed. Replacing the
+// Sampler using SetSampler also stops its summaries.
+func (s *Sampler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// sample determines if a message identified by key should be logged.
+// If the interval has elapsed, summaries of the messages suppressed
+// during it are returned.
+func (s *Sampler) sample(key sampleKey) (bool, []summary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summaries := s.rollover()
+	c, ok := s.counts[key]
+	if !ok {
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.n++
+
+	if c.n <= s.first || (s.thereafter > 0 && (c.n-s.first)%s.thereafter == 0) {
+		return true, summaries
+	}
+	c.suppressed++
+	return false, summaries
+}
+
+// expire returns summaries of the messages suppressed during the
+// current interval, and starts a new one, if the interval has elapsed.
+func (s *Sampler) expire() []summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rollover()
+}
+
+// rollover starts a new interval if the current one has elapsed,
+// returning summaries of the messages suppressed during it. The caller
+// must hold the lock.
+func (s *Sampler) rollover() []summary {
+	now := s.now()
+	if s.interval <= 0 || now.Sub(s.start) < s.interval {
+		return nil
+	}
+	s.start = now
+	return s.reset()
+}
+
+// summarise returns summaries of the messages suppressed in the current
+// interval, and resets the count of suppressed messages.
+func (s *Sampler) summarise() []summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var summaries []summary
+	for k, c := range s.counts {
+		if c.suppressed > 0 {
+			summaries = append(summaries, summary{key: k, suppressed: c.suppressed})
+			c.suppressed = 0
+		}
+	}
+	sortSummaries(summaries)
+	return summaries
+}
+
+// reset returns summaries of the messages suppressed in the current
+// interval, and clears all counts. The caller must hold the lock.
+func (s *Sampler) reset() []summary {
+	var summaries []summary
+	for k, c := range s.counts {
+		if c.suppressed > 0 {
+			summaries = append(summaries, summary{key: k, suppressed: c.suppressed})
+		}
+	}
+	s.counts = map[sampleKey]*sampleCount{}
+	sortSummaries(summaries)
+	return summaries
+}
+
+// sortSummaries sorts summaries so that they're logged in a consistent
+// order.
+func sortSummaries(summaries []summary) {
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i].key, summaries[j].key
+		if a.level != b.level {
+			return a.level < b.level
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.format < b.format
+	})
+}
+
+// SetSampler sets the Sampler used to limit how often similar messages
+// are logged. A nil Sampler logs every message.
+//
+// Until the Sampler is stopped or replaced, the MultiLogger logs
+// summaries of the messages it suppressed once per interval.
+//
+// Calling SetSampler on a child MultiLogger sets the Sampler on the
+// MultiLogger it was created from.
+func (m *MultiLogger) SetSampler(s *Sampler) {
+	r := m.root()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sampler == s {
+		return
+	}
+
+	r.sampler = s
+	if s != nil && s.interval > 0 {
+		go r.tickSummaries(s)
+	}
+}
+
+// tickSummaries logs summaries of the messages s suppressed once per
+// interval, until s is stopped or is no longer the MultiLogger's
+// Sampler.
+func (m *MultiLogger) tickSummaries(s *Sampler) {
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+		}
+
+		m.mu.RLock()
+		current := m.sampler == s
+		if current {
+			m.logSummaries(s.expire())
+		}
+		m.mu.RUnlock()
+
+		if !current {
+			return
+		}
+	}
+}
+
+// SetSampler sets the Sampler on the package-level MultiLogger.
+func SetSampler(s *Sampler) {
+	std.SetSampler(s)
+}
+
+// logSummaries logs each summary at the level of the messages it
+// summarises. Since a summary includes the key of the messages, which
+// may be their text, summaries are redacted like any other message.
+// The caller must hold the read lock.
+func (m *MultiLogger) logSummaries(summaries []summary) {
+	for _, s := range summaries {
+		e := Entry{
+			Time:    time.Now(),
+			Level:   s.key.level,
+			Name:    s.key.name,
+			Message: fmt.Sprintf("suppressed %d similar messages: %s", s.suppressed, s.key.format),
+			Fields:  []Field{{Key: "suppressed", Value: s.suppressed}},
+		}
+		if m.redactor != nil {
+			e = m.redactor.redactEntry(e)
+		}
+		m.log(e)
+	}
+}
@@ -0,0 +1,154 @@
+package iylog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultiLogger_SetSampler(t *testing.T) {
+	fl := &testFieldLogger{lvl: INFO}
+	l := NewMultiLogger(fl)
+
+	now := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := NewSampler(2, 3, time.Second)
+	s.now = func() time.Time { return now }
+	defer s.Stop()
+	l.SetSampler(s)
+
+	// The 1st, 2nd, 5th and 8th similar messages are logged.
+	for i := 0; i < 9; i++ {
+		l.Warningf("dropped count for %v", i)
+	}
+	// Messages with other formats, levels and names are counted
+	// separately, and filtered messages are not counted at all.
+	l.Infof("other %v", 1)
+	l.Errorf("dropped count for %v", 1)
+	l.Named("sh").Warningf("dropped count for %v", 1)
+	l.Debugf("dropped count for %v", 1)
+
+	var obt []string
+	for _, e := range fl.entries {
+		obt = append(obt, e.String())
+	}
+	exp := []string{
+		"[WARNING] dropped count for 0",
+		"[WARNING] dropped count for 1",
+		"[WARNING] dropped count for 4",
+		"[WARNING] dropped count for 7",
+		"[INFO] other 1",
+		"[ERROR] dropped count for 1",
+		"[WARNING] sh: dropped count for 1",
+	}
+	if fmt.Sprint(obt) != fmt.Sprint(exp) {
+		t.Fatalf(expFmt, exp, obt)
+	}
+
+	// The next message after the interval elapses is preceded by a
+	// summary.
+	fl.entries = nil
+	now = now.Add(time.Second)
+	l.Warningf("dropped count for %v", 9)
+
+	if len(fl.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v", fl.entries)
+	}
+	exp0 := "[WARNING] suppressed 5 similar messages: dropped count for %v suppressed=5"
+	if obt := fl.entries[0].String(); obt != exp0 {
+		t.Errorf(expFmt, exp0, obt)
+	}
+	if obt := fl.entries[1].String(); obt != "[WARNING] dropped count for 9" {
+		t.Errorf(expFmt, "[WARNING] dropped count for 9", obt)
+	}
+
+	// Flushing logs a summary of the current interval.
+	fl.entries = nil
+	for i := 0; i < 3; i++ {
+		l.Warning("same")
+	}
+	l.Flush()
+	exp0 = "[WARNING] suppressed 1 similar messages: same suppressed=1"
+	if len(fl.entries) != 3 || fl.entries[2].String() != exp0 {
+		t.Errorf(expFmt, exp0, fl.entries)
+	}
+}
+
+func TestMultiLogger_SetSampler_Periodic(t *testing.T) {
+	mem := NewMemLogger()
+	l := NewMultiLogger(mem)
+	s := NewSampler(1, 0, 10*time.Millisecond)
+	defer s.Stop()
+	l.SetSampler(s)
+
+	for i := 0; i < 3; i++ {
+		l.Warning("same")
+	}
+
+	// A summary is logged once the interval elapses, without any more
+	// messages being logged.
+	exp := "similar messages: same"
+	deadline := time.Now().Add(5 * time.Second)
+	for !mem.Contains(exp) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	mem.AssertContains(t, exp)
+
+	// No summaries are logged once the Sampler is stopped.
+	s.Stop()
+	mem.Reset()
+	for i := 0; i < 3; i++ {
+		l.Warning("other")
+	}
+	time.Sleep(50 * time.Millisecond)
+	mem.AssertNotContains(t, "suppressed")
+}
+
+func TestNewSampler_NoInterval(t *testing.T) {
+	fl := &testFieldLogger{lvl: INFO}
+	l := NewMultiLogger(fl)
+
+	now := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := NewSampler(1, 0, 0)
+	s.now = func() time.Time { return now }
+	l.SetSampler(s)
+
+	// The interval never ends, so only the first message is logged.
+	for i := 0; i < 3; i++ {
+		l.Warning("same")
+		now = now.Add(time.Hour)
+	}
+	if len(fl.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %v", fl.entries)
+	}
+
+	l.Flush()
+	exp := "[WARNING] suppressed 2 similar messages: same suppressed=2"
+	if len(fl.entries) != 2 || fl.entries[1].String() != exp {
+		t.Errorf(expFmt, exp, fl.entries)
+	}
+}
+
+func TestMultiLogger_SetSampler_Redacted(t *testing.T) {
+	fl := &testFieldLogger{lvl: INFO}
+	l := NewMultiLogger(fl)
+	l.SetRedactor(DefaultRedactor())
+	s := NewSampler(1, 0, time.Hour)
+	defer s.Stop()
+	l.SetSampler(s)
+
+	// The sample key of a message without a format is its text, which
+	// is redacted in the summary too.
+	l.Info("user bob@example.com logged in")
+	l.Info("user bob@example.com logged in")
+	l.Flush()
+
+	if len(fl.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v", fl.entries)
+	}
+	for _, e := range fl.entries {
+		if obt := e.String(); strings.Contains(obt, "bob@example.com") {
+			t.Errorf("expected %q to be redacted", obt)
+		}
+	}
+}
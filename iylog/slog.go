@@ -0,0 +1,179 @@
+package iylog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// fromSlogLevel maps an slog.Level to a Level.
+func fromSlogLevel(l slog.Level) Level {
+	switch {
+	case l >= slog.LevelError:
+		return ERROR
+	case l >= slog.LevelWarn:
+		return WARNING
+	case l >= slog.LevelInfo:
+		return INFO
+	case l >= slog.LevelDebug:
+		return DEBUG
+	}
+	return TRACE
+}
+
+// toSlogLevel maps a Level to an slog.Level.
+func toSlogLevel(l Level) slog.Level {
+	switch {
+	case l >= FATAL:
+		return slog.LevelError + 8
+	case l >= PANIC:
+		return slog.LevelError + 4
+	case l >= ERROR:
+		return slog.LevelError
+	case l >= WARNING:
+		return slog.LevelWarn
+	case l >= INFO:
+		return slog.LevelInfo
+	case l >= DEBUG:
+		return slog.LevelDebug
+	}
+	return slog.LevelDebug - 4
+}
+
+// SlogHandler implements the slog.Handler interface, logging records
+// through a MultiLogger, e.g.,
+//
+//	logger := slog.New(iylog.NewSlogHandler(iylog.Named("api")))
+//
+// Record levels are mapped to the nearest Level at or below them, and
+// attributes are attached as fields. Attributes within groups are
+// attached with dotted keys, e.g., "request.method".
+type SlogHandler struct {
+	m      *MultiLogger
+	fields []Field
+	group  string // prefix for attribute keys.
+}
+
+// NewSlogHandler returns a new SlogHandler, which logs through m. If m
+// is nil, the package-level MultiLogger is used.
+func NewSlogHandler(m *MultiLogger) *SlogHandler {
+	if m == nil {
+		m = std
+	}
+	return &SlogHandler{m: m}
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *SlogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	r := h.m.root()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	level := fromSlogLevel(l)
+	return r.enabled(level) && level >= r.moduleLevel(h.m.name)
+}
+
+// Handle implements the slog.Handler interface. Any fields carried by
+// ctx, or returned by the MultiLogger's Extractors, are attached.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make([]Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	h.m.withFields(fields).output(ctx, fromSlogLevel(r.Level), "", "%s", r.Message)
+	return nil
+}
+
+// WithAttrs implements the slog.Handler interface.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]Field, 0, len(h.fields)+len(attrs))
+	fields = append(fields, h.fields...)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	return &SlogHandler{m: h.m, fields: fields, group: h.group}
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{m: h.m, fields: h.fields, group: h.group + name + "."}
+}
+
+// appendAttr appends a to fields, prefixing its key with group, and
+// flattening any groups it contains.
+func appendAttr(fields []Field, group string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		prefix := group
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: group + a.Key, Value: a.Value.Any()})
+}
+
+// SlogLoggable implements the FieldLoggable interface, passing entries
+// to an slog.Handler, so that messages logged through iylog can be
+// handled by slog handlers.
+//
+// Levels are mapped to the equivalent slog.Level, fields are passed as
+// attributes, and the name of the logger, if it was created using
+// Named, is passed as the "logger" attribute.
+type SlogLoggable struct {
+	h     slog.Handler
+	level int32 // accessed atomically.
+}
+
+// NewSlogLoggable returns a new SlogLoggable, passing entries at level
+// and above to h.
+func NewSlogLoggable(h slog.Handler, level Level) *SlogLoggable {
+	return &SlogLoggable{h: h, level: int32(level)}
+}
+
+// Printf passes the formatted message to the handler, at the
+// SlogLoggable's level.
+func (l *SlogLoggable) Printf(format string, v ...interface{}) {
+	l.Log(Entry{Level: l.Level(), Message: fmt.Sprintf(format, v...)})
+}
+
+// Log passes e to the handler, if the handler is enabled at e's level.
+func (l *SlogLoggable) Log(e Entry) {
+	ctx := context.Background()
+	level := toSlogLevel(e.Level)
+	if !l.h.Enabled(ctx, level) {
+		return
+	}
+
+	r := slog.NewRecord(e.Time, level, e.Message, 0)
+	if e.Name != "" {
+		r.AddAttrs(slog.String("logger", e.Name))
+	}
+	for _, f := range e.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	l.h.Handle(ctx, r)
+}
+
+// Level returns the Level for the SlogLoggable.
+func (l *SlogLoggable) Level() Level { return Level(atomic.LoadInt32(&l.level)) }
+
+// SetLevel sets the Level for the SlogLoggable.
+func (l *SlogLoggable) SetLevel(level Level) { atomic.StoreInt32(&l.level, int32(level)) }
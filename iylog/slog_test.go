@@ -0,0 +1,106 @@
+package iylog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	fl := &testFieldLogger{lvl: INFO}
+	m := NewMultiLogger(fl).Named("api")
+
+	lg := slog.New(NewSlogHandler(m)).With("k", "v").WithGroup("req")
+	lg.Debug("ignored")
+	lg.Warn("slow request", "method", "GET", slog.Group("user", "id", 42))
+
+	if len(fl.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(fl.entries))
+	}
+
+	e := fl.entries[0]
+	if e.Level != WARNING || e.Name != "api" || e.Message != "slow request" {
+		t.Errorf(expFmt, "[WARNING] api: slow request", e.String())
+	}
+
+	exp := []Field{{Key: "k", Value: "v"}, {Key: "req.method", Value: "GET"}, {Key: "req.user.id", Value: int64(42)}}
+	if !reflect.DeepEqual(e.Fields, exp) {
+		t.Errorf(expFmt, exp, e.Fields)
+	}
+}
+
+func TestSlogHandler_Context(t *testing.T) {
+	fl := &testFieldLogger{lvl: TRACE}
+	lg := slog.New(NewSlogHandler(NewMultiLogger(fl)))
+
+	ctx := ContextWithFields(context.Background(), "request_id", "a3f9")
+	lg.Log(ctx, slog.LevelDebug-4, "trace")
+
+	if len(fl.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(fl.entries))
+	}
+	if e := fl.entries[0]; e.Level != TRACE {
+		t.Errorf(expFmt, TRACE, e.Level)
+	}
+	if v, _, _ := fieldValue(fl.entries[0].Fields, "request_id"); v != "a3f9" {
+		t.Errorf(expFmt, "a3f9", v)
+	}
+}
+
+func TestSlogLevels(t *testing.T) {
+	examples := []struct {
+		level Level
+		slog  slog.Level
+	}{
+		{level: TRACE, slog: slog.LevelDebug - 4},
+		{level: DEBUG, slog: slog.LevelDebug},
+		{level: INFO, slog: slog.LevelInfo},
+		{level: WARNING, slog: slog.LevelWarn},
+		{level: ERROR, slog: slog.LevelError},
+	}
+
+	for i, ex := range examples {
+		if obt := toSlogLevel(ex.level); obt != ex.slog {
+			t.Errorf("[Example %d] "+expFmt, i+1, ex.slog, obt)
+		}
+		if obt := fromSlogLevel(ex.slog); obt != ex.level {
+			t.Errorf("[Example %d] "+expFmt, i+1, ex.level, obt)
+		}
+	}
+
+	if obt := fromSlogLevel(toSlogLevel(FATAL)); obt != ERROR {
+		t.Errorf(expFmt, ERROR, obt)
+	}
+}
+
+func TestSlogLoggable(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	m := NewMultiLogger(NewSlogLoggable(h, DEBUG)).Named("billing")
+
+	m.Trace("ignored")
+	m.With("invoice", 7).Warningf("%d retries", 3)
+
+	var obt map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &obt); err != nil {
+		t.Fatalf("%v: %q", err, buf.String())
+	}
+	if _, err := time.Parse(time.RFC3339Nano, obt["time"].(string)); err != nil {
+		t.Error(err)
+	}
+	delete(obt, "time")
+
+	exp := map[string]interface{}{
+		"level":   "WARN",
+		"msg":     "3 retries",
+		"logger":  "billing",
+		"invoice": float64(7),
+	}
+	if !reflect.DeepEqual(obt, exp) {
+		t.Errorf(expFmt, exp, obt)
+	}
+}
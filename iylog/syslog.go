@@ -0,0 +1,308 @@
+package iylog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Facility is a syslog facility, as defined in RFC 5424.
+type Facility int
+
+// Supported syslog facilities.
+const (
+	FacilityKern   Facility = 0
+	FacilityUser   Facility = 1
+	FacilityDaemon Facility = 3
+	FacilityAuth   Facility = 4
+	FacilityLocal0 Facility = 16
+	FacilityLocal1 Facility = 17
+	FacilityLocal2 Facility = 18
+	FacilityLocal3 Facility = 19
+	FacilityLocal4 Facility = 20
+	FacilityLocal5 Facility = 21
+	FacilityLocal6 Facility = 22
+	FacilityLocal7 Facility = 23
+)
+
+// sdID is the structured data ID fields are written under. 32473 is
+// the private enterprise number reserved for documentation and
+// examples in RFC 5612.
+const sdID = "fields@32473"
+
+// DefaultSyslogTimeout is the default time a SyslogLogger waits to
+// connect to the syslog server, or to send a message to it.
+const DefaultSyslogTimeout = 5 * time.Second
+
+// severity maps a Level to a syslog severity.
+func severity(l Level) int {
+	switch {
+	case l >= PANIC:
+		return 2 // crit
+	case l >= ERROR:
+		return 3 // err
+	case l >= WARNING:
+		return 4 // warning
+	case l >= INFO:
+		return 6 // info
+	}
+	return 7 // debug
+}
+
+// SyslogLogger implements the FieldLoggable interface, sending each
+// entry to a syslog server as an RFC 5424 message, e.g.,
+//
+//	<14>1 2016-01-02T15:04:05.000000Z host app 123 - [fields@32473 user_id="42"] hello
+//
+// The entry's level is mapped to a syslog severity, and its fields are
+// sent as structured data, along with the name of the logger, if it was
+// created using Named.
+//
+// Messages sent over stream connections ("tcp" and "unix") are framed
+// using octet counting, as described by RFC 6587. Messages sent over
+// datagram connections ("udp" and "unixgram") are sent one per
+// datagram.
+//
+// Connecting and sending a message each time out after
+// DefaultSyslogTimeout, so that a slow or unresponsive syslog server
+// doesn't block logging indefinitely. The timeout can be changed with
+// WithSyslogTimeout.
+//
+// If sending a message fails, the SyslogLogger reconnects and tries
+// again once. If that fails, the message is discarded, as with a
+// log.Logger, and the SyslogLogger reconnects when the next message is
+// logged.
+//
+// A SyslogLogger is safe for use by multiple goroutines.
+type SyslogLogger struct {
+	level    int32 // accessed atomically.
+	network  string
+	addr     string
+	facility Facility
+	hostname string
+	appName  string
+	pid      string
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+	buf    bytes.Buffer
+}
+
+// SyslogOption is a functional option for the SyslogLogger type.
+type SyslogOption func(*SyslogLogger)
+
+// WithFacility sets the facility messages are sent with. The default
+// is FacilityUser.
+func WithFacility(f Facility) SyslogOption {
+	return func(l *SyslogLogger) {
+		l.facility = f
+	}
+}
+
+// WithAppName sets the application name messages are sent with. The
+// default is the name of the running program.
+func WithAppName(name string) SyslogOption {
+	return func(l *SyslogLogger) {
+		l.appName = name
+	}
+}
+
+// WithHostname sets the hostname messages are sent with. The default
+// is the hostname reported by the kernel.
+func WithHostname(name string) SyslogOption {
+	return func(l *SyslogLogger) {
+		l.hostname = name
+	}
+}
+
+// WithSyslogTimeout sets the time the SyslogLogger waits to connect to
+// the syslog server, or to send a message to it. The default is
+// DefaultSyslogTimeout.
+func WithSyslogTimeout(d time.Duration) SyslogOption {
+	return func(l *SyslogLogger) {
+		l.timeout = d
+	}
+}
+
+// NewSyslogLogger returns a new SyslogLogger, connected to the syslog
+// server at addr on the named network, as understood by net.Dial.
+func NewSyslogLogger(network, addr string, level Level, options ...SyslogOption) (*SyslogLogger, error) {
+	l := &SyslogLogger{
+		level:    int32(level),
+		network:  network,
+		addr:     addr,
+		facility: FacilityUser,
+		appName:  filepath.Base(os.Args[0]),
+		pid:      strconv.Itoa(os.Getpid()),
+		timeout:  DefaultSyslogTimeout,
+	}
+	l.hostname, _ = os.Hostname()
+
+	// Apply any options.
+	for _, option := range options {
+		option(l)
+	}
+
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// connect dials the syslog server. The caller must hold the lock, if
+// the SyslogLogger is in use.
+func (l *SyslogLogger) connect() error {
+	conn, err := net.DialTimeout(l.network, l.addr, l.timeout)
+	if err != nil {
+		return err
+	}
+	l.conn = conn
+	return nil
+}
+
+// write sends the buffered message, giving up after the timeout. The
+// caller must hold the lock.
+func (l *SyslogLogger) write() error {
+	l.conn.SetWriteDeadline(time.Now().Add(l.timeout))
+	_, err := l.conn.Write(l.buf.Bytes())
+	return err
+}
+
+// Printf sends the formatted message at the SyslogLogger's level.
+func (l *SyslogLogger) Printf(format string, v ...interface{}) {
+	l.Log(Entry{Level: l.Level(), Message: fmt.Sprintf(format, v...)})
+}
+
+// Log sends e to the syslog server.
+func (l *SyslogLogger) Log(e Entry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+
+	msg := l.format(e)
+	l.buf.Reset()
+	switch l.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		l.buf.WriteString(strconv.Itoa(len(msg)) + " ")
+	}
+	l.buf.Write(msg)
+
+	if l.conn != nil {
+		if err := l.write(); err == nil {
+			return
+		}
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	if err := l.connect(); err == nil {
+		l.write()
+	}
+}
+
+// format renders e as an RFC 5424 message.
+func (l *SyslogLogger) format(e Entry) []byte {
+	var b bytes.Buffer
+	pri := int(l.facility)*8 + severity(e.Level)
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s - ",
+		pri,
+		e.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeader(l.hostname, 255),
+		syslogHeader(l.appName, 48),
+		syslogHeader(l.pid, 128),
+	)
+
+	fields := e.Fields
+	if e.Name != "" {
+		fields = append([]Field{{Key: "logger", Value: e.Name}}, fields...)
+	}
+
+	if len(fields) == 0 {
+		b.WriteString("-")
+	} else {
+		b.WriteString("[" + sdID)
+		for _, f := range fields {
+			fmt.Fprintf(&b, " %s=\"%s\"", sdName(f.Key), sdEscaper.Replace(fmt.Sprint(f.Value)))
+		}
+		b.WriteString("]")
+	}
+
+	b.WriteString(" " + e.Message)
+	return b.Bytes()
+}
+
+// sdEscaper escapes structured data parameter values.
+var sdEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// syslogHeader returns s as a valid RFC 5424 header field, of at most
+// max printable ASCII characters, or "-" if s is empty.
+func syslogHeader(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, s)
+
+	if s == "" {
+		return "-"
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+// sdName returns s as a valid RFC 5424 structured data parameter name.
+func sdName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, s)
+
+	if s == "" {
+		return "_"
+	}
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	return s
+}
+
+// Level returns the Level for the SyslogLogger.
+func (l *SyslogLogger) Level() Level { return Level(atomic.LoadInt32(&l.level)) }
+
+// SetLevel sets the Level for the SyslogLogger.
+func (l *SyslogLogger) SetLevel(level Level) { atomic.StoreInt32(&l.level, int32(level)) }
+
+// Close closes the connection to the syslog server. Messages logged
+// after Close is called are discarded.
+func (l *SyslogLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	if l.conn == nil {
+		return nil
+	}
+
+	err := l.conn.Close()
+	l.conn = nil
+	return err
+}
@@ -0,0 +1,156 @@
+package iylog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenUnixgram returns a unixgram socket in a temporary directory,
+// standing in for a syslog server or the journal.
+func listenUnixgram(t *testing.T) *net.UnixConn {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not supported on windows")
+	}
+
+	addr := &net.UnixAddr{Name: filepath.Join(t.TempDir(), "log.sock"), Net: "unixgram"}
+	conn, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readDatagram reads a single datagram from conn.
+func readDatagram(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogLogger_Unixgram(t *testing.T) {
+	srv := listenUnixgram(t)
+
+	sl, err := NewSyslogLogger("unixgram", srv.LocalAddr().String(), INFO,
+		WithFacility(FacilityLocal0),
+		WithAppName("app"),
+		WithHostname("host"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+
+	l := NewMultiLogger(sl)
+	l.Named("billing").With("user id", 42, "q", `a"]\`).Warningf("hello %s", "world")
+
+	// local0 (16) * 8 + warning (4) = 132
+	exp := regexp.MustCompile(`^<132>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(Z|[+-]\d\d:\d\d) host app \d+ - ` +
+		regexp.QuoteMeta(`[fields@32473 logger="billing" user_id="42" q="a\"\]\\"] hello world`) + `$`)
+	if obt := readDatagram(t, srv); !exp.MatchString(obt) {
+		t.Errorf(expFmt, exp, obt)
+	}
+
+	sl.Printf("plain")
+	exp = regexp.MustCompile(`^<134>1 .* - - plain$`)
+	if obt := readDatagram(t, srv); !exp.MatchString(obt) {
+		t.Errorf(expFmt, exp, obt)
+	}
+}
+
+func TestSyslogLogger_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	sl, err := NewSyslogLogger("tcp", ln.Addr().String(), DEBUG, WithAppName("app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sl.Log(Entry{Level: ERROR, Message: "boom"})
+
+	// Messages are framed with their length.
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	length, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := regexp.MustCompile(`^<11>1 .* app \d+ - - boom$`)
+	if !exp.Match(msg) {
+		t.Errorf(expFmt, exp, string(msg))
+	}
+}
+
+func TestSyslogLogger_Timeout(t *testing.T) {
+	// The server accepts connections, but never reads from them.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	sl, err := NewSyslogLogger("tcp", ln.Addr().String(), DEBUG, WithSyslogTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sl.Close()
+
+	// Logging gives up once the connection's buffers are full, rather
+	// than blocking.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		msg := strings.Repeat("x", 1<<20)
+		for i := 0; i < 20; i++ {
+			sl.Log(Entry{Level: INFO, Message: msg})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected Log to time out")
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	examples := map[Level]int{TRACE: 7, DEBUG: 7, INFO: 6, WARNING: 4, ERROR: 3, PANIC: 2, FATAL: 2}
+	for l, exp := range examples {
+		if obt := severity(l); obt != exp {
+			t.Errorf("%v: "+expFmt, l, exp, obt)
+		}
+	}
+}
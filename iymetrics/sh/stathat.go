@@ -50,6 +50,10 @@ type StatHat struct {
 	mu     sync.Mutex
 	key    string
 	prefix string
+
+	pmu     sync.Mutex
+	pcond   *sync.Cond
+	pending int // stats queued, but not yet sent.
 }
 
 // Option is a functional option for the StatHat type.
@@ -83,6 +87,7 @@ func New(options ...Option) *StatHat {
 	}
 	s.countF = s.sendCount
 	s.measureF = s.sendMeasure
+	s.pcond = sync.NewCond(&s.pmu)
 
 	// Apply any options.
 	for _, option := range options {
@@ -100,6 +105,7 @@ func New(options ...Option) *StatHat {
 			if err := stathat.PostEZCount(c.name, c.key, c.n); err != nil {
 				iylog.Warning(err)
 			}
+			s.track(-1)
 		}
 	}(s.countC)
 
@@ -108,6 +114,7 @@ func New(options ...Option) *StatHat {
 			if err := stathat.PostEZValue(m.name, m.key, m.v); err != nil {
 				iylog.Warning(err)
 			}
+			s.track(-1)
 		}
 	}(s.measureC)
 	return s
@@ -175,9 +182,11 @@ func (s *StatHat) MonitorRuntime(d time.Duration) {
 // sendCount sends a count down the count channel, dropping the count on
 // the floor, if the channel is full.
 func (s *StatHat) sendCount(name, key string, n int) {
+	s.track(1)
 	select {
 	case s.countC <- count{name: name, key: key, n: n}:
 	default:
+		s.track(-1)
 		iylog.Warningf("dropped count for %v", name)
 	}
 }
@@ -204,13 +213,43 @@ func (s *StatHat) Count(name string, n int) {
 // sendMeasure sends a measure down the measure channel, dropping the
 // measure on the floor, if the channel is full.
 func (s *StatHat) sendMeasure(name, key string, v float64) {
+	s.track(1)
 	select {
 	case s.measureC <- measure{name: name, key: key, v: v}:
 	default:
+		s.track(-1)
 		iylog.Warningf("dropped measure for %v", name)
 	}
 }
 
+// track adjusts the number of stats queued but not yet sent by n,
+// waking any callers of Flush once all stats have been sent.
+func (s *StatHat) track(n int) {
+	s.pmu.Lock()
+	defer s.pmu.Unlock()
+	s.pending += n
+	if s.pending == 0 {
+		s.pcond.Broadcast()
+	}
+}
+
+// Flush calls Flush on the package-level StatHat instance.
+func Flush() error {
+	return std.Flush()
+}
+
+// Flush blocks until all queued stats have been sent to the StatHat
+// service, e.g., before the process exits. Stats which couldn't be
+// sent are logged, rather than returned as errors.
+func (s *StatHat) Flush() error {
+	s.pmu.Lock()
+	defer s.pmu.Unlock()
+	for s.pending > 0 {
+		s.pcond.Wait()
+	}
+	return nil
+}
+
 // Measure calls Measure on the package-level StatHat instance.
 func Measure(name string, v float64) {
 	std.Measure(name, v)
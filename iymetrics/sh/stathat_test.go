@@ -297,3 +297,43 @@ func TestStatHat_sendMeasure(t *testing.T) {
 		t.Error("default case should have been triggered")
 	}
 }
+
+func TestStatHat_Flush(t *testing.T) {
+	s := New()
+	flushed := make(chan error, 1)
+
+	// It blocks until every queued stat has been sent.
+	s.track(2)
+	go func() { flushed <- s.Flush() }()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-flushed:
+			t.Fatalf("expected Flush to block with %d stats queued", 2-i)
+		case <-time.After(20 * time.Millisecond):
+		}
+		s.track(-1)
+	}
+
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Errorf("expected %v, got %v", nil, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Flush")
+	}
+
+	// It doesn't wait for stats which were dropped on the floor.
+	s.countC = make(chan count)
+	s.sendCount("foo", "key", 2)
+	if s.pending != 0 {
+		t.Errorf("expected %v, got %v", 0, s.pending)
+	}
+
+	go func() { flushed <- s.Flush() }()
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Flush")
+	}
+}